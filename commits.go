@@ -0,0 +1,243 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/spf13/cobra"
+)
+
+type LogFlags struct {
+    MaxCount   int
+    LinkedOnly bool
+    Export     bool
+}
+
+var (
+    linkAuto   bool
+    linkExport bool
+    logFlags   = &LogFlags{}
+)
+
+var linkCmd = &cobra.Command{
+    Use:   "link [note] [rev]",
+    Short: "Link a note to a commit (HEAD by default)",
+    Long: `Link a note to a commit so "jot log" can show why the commit was made.
+
+The note is matched by ID, then by title in the current project and branch.
+With --auto every note filed under the current branch is linked to HEAD,
+which is what the hook installed by "jot hook" runs after each commit. Notes
+of the branch in every sub-project are linked. It does nothing unless HEAD
+is on a branch or a rebase of one.`,
+    Args: cobra.RangeArgs(0, 2),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := initializeApp()
+        if err != nil {
+            return err
+        }
+        defer store.Close()
+        repo := resolver.Context()
+        if !repo.IsRepo() {
+            return fmt.Errorf("not a git repository")
+        }
+
+        rev := "HEAD"
+        var notes []*storage.Note
+        if linkAuto {
+            if len(args) > 1 {
+                return fmt.Errorf("--auto takes at most a revision")
+            }
+            if len(args) == 1 {
+                rev = args[0]
+            }
+            all, err := store.GetAll()
+            if err != nil {
+                return fmt.Errorf("error fetching notes: %v", err)
+            }
+            notes = autoLinkNotes(all, repo)
+        } else {
+            if len(args) == 0 {
+                return fmt.Errorf("a note title or ID is required")
+            }
+            if len(args) == 2 {
+                rev = args[1]
+            }
+            scope, _ := repo.Scope(cfg.DetachedHead)
+            note, err := findNote(store, args[0], scope)
+            if err != nil {
+                return err
+            }
+            notes = append(notes, note)
+        }
+
+        sha, err := gitctx.ResolveCommit(repo.Root, rev)
+        if err != nil {
+            return fmt.Errorf("unknown revision %s: %w", rev, err)
+        }
+        for _, note := range notes {
+            if err := linkNote(store, repo, note, sha, linkExport || cfg.GitNotes); err != nil {
+                return err
+            }
+            if !linkAuto {
+                fmt.Printf("Linked '%s' to %s\n", note.Title, sha[:7])
+            }
+        }
+        return nil
+    },
+}
+
+var hookCmd = &cobra.Command{
+    Use:   "hook",
+    Short: "Install a post-commit hook that links branch notes to new commits",
+    Args:  cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        repo := resolver.Context()
+        if !repo.IsRepo() {
+            return fmt.Errorf("not a git repository")
+        }
+        path, err := gitctx.InstallHook(repo.Root, "post-commit", "jot link --auto >/dev/null 2>&1 || true")
+        if err != nil {
+            return fmt.Errorf("error installing hook: %w", err)
+        }
+        fmt.Printf("Installed post-commit hook in %s\n", path)
+        return nil
+    },
+}
+
+var logCmd = &cobra.Command{
+    Use:   "log [range]",
+    Short: "Show commits with the notes linked to them",
+    Args:  cobra.MaximumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := initializeApp()
+        if err != nil {
+            return err
+        }
+        defer store.Close()
+        repo := resolver.Context()
+        if !repo.IsRepo() {
+            return fmt.Errorf("not a git repository")
+        }
+
+        revRange := ""
+        if len(args) == 1 {
+            revRange = args[0]
+        }
+        commits, err := gitctx.Log(repo.Root, revRange, logFlags.MaxCount)
+        if err != nil {
+            return fmt.Errorf("error reading git log: %w", err)
+        }
+
+        for _, commit := range commits {
+            notes, err := store.GetByCommit(commit.SHA)
+            if err != nil {
+                return fmt.Errorf("error fetching notes: %v", err)
+            }
+            if logFlags.LinkedOnly && len(notes) == 0 {
+                continue
+            }
+
+            fmt.Printf("%s  %s  %s\n", commit.Short, commit.Date.Format("2006-01-02"), commit.Subject)
+            for _, note := range notes {
+                fmt.Printf("         ↳ %s (%s)  %s\n", note.Title, noteScope(note), note.Path)
+                if logFlags.Export {
+                    if err := gitctx.AddNote(repo.Root, commit.SHA, note.GitNoteMessage()); err != nil {
+                        return fmt.Errorf("error exporting git note: %w", err)
+                    }
+                }
+            }
+        }
+        return nil
+    },
+}
+
+func init() {
+    linkCmd.Flags().BoolVar(&linkAuto, "auto", false, "Link every note of the current branch")
+    linkCmd.Flags().BoolVar(&linkExport, "export", false, "Also export a pointer to "+gitctx.NotesRef)
+
+    logCmd.Flags().IntVarP(&logFlags.MaxCount, "max-count", "n", 20, "Limit the number of commits (0 for all)")
+    logCmd.Flags().BoolVar(&logFlags.LinkedOnly, "linked", false, "Only show commits with linked notes")
+    logCmd.Flags().BoolVar(&logFlags.Export, "export", false, "Export linked notes to "+gitctx.NotesRef)
+
+    rootCmd.AddCommand(linkCmd)
+    rootCmd.AddCommand(hookCmd)
+    rootCmd.AddCommand(logCmd)
+}
+
+func linkNote(store storage.NoteStore, repo gitctx.Context, note *storage.Note, sha string, export bool) error {
+    if err := store.LinkCommit(note.ID, sha); err != nil {
+        return fmt.Errorf("error linking note: %v", err)
+    }
+    if export {
+        if err := gitctx.AddNote(repo.Root, sha, note.GitNoteMessage()); err != nil {
+            return fmt.Errorf("error exporting git note: %w", err)
+        }
+    }
+    return nil
+}
+
+func noteScope(note *storage.Note) string {
+    scope := note.Scope()
+    if scope.Branch == "*" || scope.Branch == "" {
+        return scope.Label()
+    }
+    return scope.Label() + "/" + scope.Branch
+}
+
+// findNote looks a note up by ID, then by title within scope, then by a title
+// that is unique across all notes.
+func findNote(store storage.NoteStore, query string, scope gitctx.Scope) (*storage.Note, error) {
+    notes, err := store.GetAll()
+    if err != nil {
+        return nil, fmt.Errorf("error fetching notes: %v", err)
+    }
+
+    var byTitle []*storage.Note
+    for _, note := range notes {
+        if note.ID == query {
+            return note, nil
+        }
+        if note.Title == query {
+            byTitle = append(byTitle, note)
+        }
+    }
+
+    for _, note := range byTitle {
+        if note.Scope() == scope {
+            return note, nil
+        }
+    }
+    switch len(byTitle) {
+    case 0:
+        return nil, fmt.Errorf("no note matches '%s'", query)
+    case 1:
+        return byTitle[0], nil
+    }
+
+    var scopes []string
+    for _, note := range byTitle {
+        scopes = append(scopes, noteScope(note))
+    }
+    return nil, fmt.Errorf("'%s' matches notes in %s, use the note ID",
+        query, strings.Join(scopes, ", "))
+}
+
+// autoLinkNotes picks the notes "link --auto" links: those of the current
+// branch in every sub-project, since hooks run from the top of the working
+// tree where no sub-project applies. Only branches have branch notes; a
+// detached HEAD, such as during "git rebase -x", links nothing.
+func autoLinkNotes(notes []*storage.Note, repo gitctx.Context) []*storage.Note {
+    if repo.State != gitctx.StateBranch && repo.State != gitctx.StateRebasing {
+        return nil
+    }
+    var linked []*storage.Note
+    for _, note := range notes {
+        if note.Project == repo.Project && note.Branch == repo.Branch {
+            linked = append(linked, note)
+        }
+    }
+    return linked
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+    "os/exec"
+    "path/filepath"
+    "strings"
+    "testing"
+
+    "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+)
+
+func TestAutoLinkNotes(t *testing.T) {
+    notes := []*storage.Note{
+        {ID: "root", Project: "mono", Branch: "feature"},
+        {ID: "billing", Project: "mono", SubProject: "billing", Branch: "feature"},
+        {ID: "wide", Project: "mono", SubProject: "billing", Branch: "*"},
+        {ID: "other-branch", Project: "mono", Branch: "main"},
+        {ID: "other-project", Project: "web", Branch: "feature"},
+    }
+    tests := []struct {
+        name string
+        repo gitctx.Context
+        want string
+    }{
+        // Hooks run from the top of the tree, where no sub-project applies
+        {"branch", gitctx.Context{State: gitctx.StateBranch, Project: "mono", Branch: "feature"}, "root billing"},
+        {"rebase", gitctx.Context{State: gitctx.StateRebasing, Project: "mono", Branch: "feature"}, "root billing"},
+        {"detached", gitctx.Context{State: gitctx.StateDetached, Project: "mono"}, ""},
+        {"bisect", gitctx.Context{State: gitctx.StateBisecting, Project: "mono", Branch: "feature"}, ""},
+    }
+    for _, test := range tests {
+        var ids []string
+        for _, note := range autoLinkNotes(notes, test.repo) {
+            ids = append(ids, note.ID)
+        }
+        if got := strings.Join(ids, " "); got != test.want {
+            t.Errorf("%s: linked %q, want %q", test.name, got, test.want)
+        }
+    }
+}
+
+func TestLinkNote(t *testing.T) {
+    home, repoDir := t.TempDir(), t.TempDir()
+    t.Setenv("HOME", home)
+    t.Setenv("GIT_CONFIG_GLOBAL", "/dev/null")
+    for _, name := range []string{"AUTHOR", "COMMITTER"} {
+        t.Setenv("GIT_"+name+"_NAME", "jot")
+        t.Setenv("GIT_"+name+"_EMAIL", "jot@example.com")
+    }
+    for _, args := range [][]string{
+        {"init", "-q", "-b", "main"},
+        {"commit", "-q", "--allow-empty", "-m", "first"},
+        {"commit", "-q", "--allow-empty", "-m", "second"},
+    } {
+        if out, err := exec.Command("git", append([]string{"-C", repoDir}, args...)...).CombinedOutput(); err != nil {
+            t.Fatalf("git %v: %v\n%s", args, err, out)
+        }
+    }
+    repo := gitctx.Resolve(repoDir)
+
+    store, err := storage.NewSQLiteStore(filepath.Join(home, "notes.db"), &config.Config{})
+    if err != nil {
+        t.Fatal(err)
+    }
+    defer store.Close()
+    note, err := store.Create(storage.Note{Title: "why", Project: repo.Project, Branch: repo.Branch})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    sha, err := gitctx.ResolveCommit(repo.Root, "HEAD~1")
+    if err != nil {
+        t.Fatal(err)
+    }
+    if err := linkNote(store, repo, note, sha, true); err != nil {
+        t.Fatal(err)
+    }
+
+    commits, err := gitctx.Log(repo.Root, "", 0)
+    if err != nil {
+        t.Fatal(err)
+    }
+    linked := map[string]int{}
+    for _, commit := range commits {
+        notes, err := store.GetByCommit(commit.SHA)
+        if err != nil {
+            t.Fatal(err)
+        }
+        linked[commit.Subject] = len(notes)
+    }
+    if linked["first"] != 1 || linked["second"] != 0 {
+        t.Errorf("linked notes per commit = %v, want only first", linked)
+    }
+    out, err := exec.Command("git", "-C", repo.Root, "notes", "--ref="+gitctx.NotesRef, "show", sha).Output()
+    if err != nil || !strings.Contains(string(out), note.GitNoteMessage()) {
+        t.Errorf("git note = %q, %v, want %q", out, err, note.GitNoteMessage())
+    }
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+    "fmt"
+    "strings"
+
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/spf13/cobra"
+)
+
+type CopyFlags struct {
+    Title   string
+    Branch  string
+    Project string
+}
+
+var copyFlags = &CopyFlags{}
+
+var copyCmd = &cobra.Command{
+    Use:   "cp [note]",
+    Short: "Copy a note's content and tags into a new note",
+    Long: `Copy a note's content and tags into a new note, with its header rewritten
+for the copy's title and scope. The copy goes to the current project and
+branch unless --project or --branch say otherwise; --branch "*" files it
+project-wide.
+
+The note is matched by ID, then by title in the current project and branch.`,
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := initializeApp()
+        if err != nil {
+            return err
+        }
+        defer store.Close()
+        repo := resolver.Context()
+        scope, err := resolveScope(repo)
+        if err != nil {
+            return err
+        }
+        source, err := findNote(store, args[0], scope)
+        if err != nil {
+            return err
+        }
+
+        if copyFlags.Project != "" {
+            scope.Project, scope.SubProject, _ = strings.Cut(copyFlags.Project, "/")
+        }
+        if copyFlags.Branch != "" {
+            scope.Branch = copyFlags.Branch
+        }
+        ticket := repo.TicketFor(scope)
+        if scope == source.Scope() {
+            ticket = source.Ticket
+        }
+        opts := []storage.UpdateOption{
+            storage.WithProject(scope.Project),
+            storage.WithSubProject(scope.SubProject),
+            storage.WithBranch(scope.Branch),
+            storage.WithTicket(ticket),
+        }
+        if copyFlags.Title != "" {
+            opts = append(opts, storage.WithTitle(copyFlags.Title))
+        }
+
+        note, err := store.Copy(source.ID, opts...)
+        if err != nil {
+            return fmt.Errorf("error copying note: %v", err)
+        }
+        if fromNvim {
+            fmt.Print(note.Path)
+            return nil
+        }
+        fmt.Printf("Copied '%s' to '%s' in %s\n", source.Title, note.Title, noteScope(note))
+        return nil
+    },
+}
+
+func init() {
+    copyCmd.Flags().StringVarP(&copyFlags.Title, "title", "t", "", "Title of the copy (the note's own by default)")
+    copyCmd.Flags().StringVarP(&copyFlags.Branch, "branch", "b", "", "Branch to copy to, * for project-wide")
+    copyCmd.Flags().StringVarP(&copyFlags.Project, "project", "p", "", "Project[/subproject] to copy to")
+
+    rootCmd.AddCommand(copyCmd)
+}
@@ -10,6 +10,30 @@ type Config struct {
 	Editor           string `json:"editor,omitempty"`
 	EditorBackground bool   `json:"editor_background,omitempty"`
 	DefaultMode      string `json:"default_mode,omitempty"`
+	// DetachedHead selects where notes go when HEAD is detached:
+	// "global" (project-wide, default), "prompt" or "revision".
+	DetachedHead string `json:"detached_head,omitempty"`
+	// GitNotes exports a pointer to refs/notes/jot whenever a note is
+	// linked to a commit.
+	GitNotes bool `json:"git_notes,omitempty"`
+	// Sort is the order of the TUI and jot list: "frecency" (default),
+	// "modified", "created", "title" or "scope".
+	Sort string `json:"sort,omitempty"`
+	// Compact hides the TUI banner. Short terminals always hide it.
+	Compact bool `json:"compact,omitempty"`
+	// Theme names the TUI color scheme, a built-in or a file in
+	// ~/.jot/themes. "auto" (default) follows the terminal background,
+	// using LightTheme and DarkTheme when they are set.
+	Theme      string `json:"theme,omitempty"`
+	LightTheme string `json:"light_theme,omitempty"`
+	DarkTheme  string `json:"dark_theme,omitempty"`
+	// KeyPreset picks the TUI's base keymap: "default", "vim" or "emacs".
+	KeyPreset string `json:"key_preset,omitempty"`
+	// Keys rebinds TUI actions, e.g. {"search_open": ["enter"]}.
+	Keys map[string][]string `json:"keys,omitempty"`
+	// RecentCommands are the TUI command palette's last commands, newest
+	// first.
+	RecentCommands []string `json:"recent_commands,omitempty"`
 }
 
 func Load() (*Config, error) {
@@ -57,3 +81,34 @@ func (c *Config) Save() error {
 
 	return os.WriteFile(configPath, data, 0644)
 }
+
+// RepoConfigFile is the per-repository configuration file, read from the
+// repository root.
+const RepoConfigFile = ".jot.json"
+
+// RepoConfig holds settings that belong to a single repository.
+type RepoConfig struct {
+	// SubProjects maps path patterns relative to the repository root, such
+	// as "services/billing/**", to sub-project names. An empty name uses the
+	// directory matched by the last segment of the pattern, so
+	// "services/*/**" gives every service its own sub-project. "**" may
+	// only be the last segment; patterns with segments after it are ignored.
+	SubProjects map[string]string `json:"subprojects,omitempty"`
+}
+
+func LoadRepo(root string) (*RepoConfig, error) {
+	data, err := os.ReadFile(filepath.Join(root, RepoConfigFile))
+	if os.IsNotExist(err) {
+		return &RepoConfig{}, nil
+	}
+	if err != nil {
+		return &RepoConfig{}, err
+	}
+
+	var config RepoConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return &RepoConfig{}, err
+	}
+
+	return &config, nil
+}
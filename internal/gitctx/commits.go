@@ -0,0 +1,126 @@
+package gitctx
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// NotesRef is the git notes ref jot exports note pointers to, so that
+// `git log --notes=jot` shows them.
+const NotesRef = "refs/notes/jot"
+
+// hookMarker identifies the line jot adds to a hook script.
+const hookMarker = "# added by jot"
+
+// Commit is a single entry of git log.
+type Commit struct {
+	SHA     string
+	Short   string
+	Subject string
+	Date    time.Time
+}
+
+// ResolveCommit returns the full SHA of rev, which may be any revision git
+// understands (HEAD, a branch, a tag, an abbreviated SHA, ...).
+func ResolveCommit(dir, rev string) (string, error) {
+	return git(dir, "rev-parse", "--verify", "--quiet", rev+"^{commit}")
+}
+
+// Log lists the commits in revRange (HEAD when empty), newest first. A limit
+// of zero lists every commit.
+func Log(dir, revRange string, limit int) ([]Commit, error) {
+	args := []string{"log", "--format=%H%x1f%h%x1f%s%x1f%aI"}
+	if limit > 0 {
+		args = append(args, fmt.Sprintf("-n%d", limit))
+	}
+	if revRange != "" {
+		args = append(args, revRange)
+	}
+	args = append(args, "--")
+
+	out, err := git(dir, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		commits = append(commits, Commit{
+			SHA:     fields[0],
+			Short:   fields[1],
+			Subject: fields[2],
+			Date:    date,
+		})
+	}
+	return commits, nil
+}
+
+// AddNote appends message to the jot git note of a commit. Messages already
+// present in the note are not added again.
+func AddNote(dir, sha, message string) error {
+	existing, _ := git(dir, "notes", "--ref="+NotesRef, "show", sha)
+	if strings.Contains(existing, message) {
+		return nil
+	}
+	_, err := git(dir, "notes", "--ref="+NotesRef, "append", "-m", message, sha)
+	return err
+}
+
+// InstallHook makes the named hook (e.g. "post-commit") run command,
+// creating the hook script if needed. The command goes before a trailing
+// exit, which would otherwise stop it from running. It respects
+// core.hooksPath and returns the path of the hook script.
+func InstallHook(dir, name, command string) (string, error) {
+	hooksDir, err := git(dir, "rev-parse", "--path-format=absolute", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return "", err
+	}
+
+	hookPath := filepath.Join(hooksDir, name)
+	script, err := os.ReadFile(hookPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if strings.Contains(string(script), hookMarker) {
+		return hookPath, nil
+	}
+
+	content := addHookLine(string(script), command+" "+hookMarker)
+	if err := os.WriteFile(hookPath, []byte(content), 0755); err != nil {
+		return "", err
+	}
+	return hookPath, nil
+}
+
+// addHookLine adds line to a hook script, before its last command when that
+// is an exit
+func addHookLine(script, line string) string {
+	if script == "" {
+		return "#!/bin/sh\n" + line + "\n"
+	}
+	lines := strings.Split(strings.TrimRight(script, "\n"), "\n")
+	at := len(lines)
+	for i := len(lines) - 1; i > 0; i-- {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if trimmed == "exit" || strings.HasPrefix(trimmed, "exit ") {
+			at = i
+		}
+		break
+	}
+	lines = append(lines[:at], append([]string{line}, lines[at:]...)...)
+	return strings.Join(lines, "\n") + "\n"
+}
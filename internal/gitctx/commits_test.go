@@ -0,0 +1,85 @@
+package gitctx
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestAddHookLine(t *testing.T) {
+	tests := []struct {
+		script, want string
+	}{
+		{"", "#!/bin/sh\njot\n"},
+		{"#!/bin/sh\necho hi", "#!/bin/sh\necho hi\njot\n"},
+		{"#!/bin/sh\necho hi\nexit 0\n", "#!/bin/sh\necho hi\njot\nexit 0\n"},
+		{"#!/bin/sh\nexit\n# done\n\n", "#!/bin/sh\njot\nexit\n# done\n"},
+		{"#!/bin/sh\nexit_code=1\n", "#!/bin/sh\nexit_code=1\njot\n"},
+	}
+	for _, test := range tests {
+		if got := addHookLine(test.script, "jot"); got != test.want {
+			t.Errorf("addHookLine(%q) = %q, want %q", test.script, got, test.want)
+		}
+	}
+}
+
+func TestLogAndNotes(t *testing.T) {
+	repo := t.TempDir()
+	run(t, repo, "init", "-q", "-b", "main")
+	for _, message := range []string{"one", "two", "three"} {
+		run(t, repo, "commit", "-q", "--allow-empty", "-m", message)
+	}
+
+	head, err := ResolveCommit(repo, "HEAD")
+	if err != nil || len(head) != 40 {
+		t.Fatalf("ResolveCommit(HEAD) = %q, %v", head, err)
+	}
+	if _, err := ResolveCommit(repo, "missing"); err == nil {
+		t.Error("ResolveCommit(missing) succeeded")
+	}
+
+	tests := []struct {
+		revRange string
+		limit    int
+		want     string
+	}{
+		{"", 0, "three two one"},
+		{"", 2, "three two"},
+		{"HEAD~2..HEAD", 0, "three two"},
+		{"main~1", 0, "two one"},
+	}
+	for _, test := range tests {
+		commits, err := Log(repo, test.revRange, test.limit)
+		if err != nil {
+			t.Fatalf("Log(%q, %d): %v", test.revRange, test.limit, err)
+		}
+		subjects := make([]string, len(commits))
+		for i, commit := range commits {
+			subjects[i] = commit.Subject
+		}
+		if got := strings.Join(subjects, " "); got != test.want {
+			t.Errorf("Log(%q, %d) = %s, want %s", test.revRange, test.limit, got, test.want)
+		}
+		if len(commits) > 0 && (!strings.HasPrefix(commits[0].SHA, commits[0].Short) || commits[0].Date.IsZero()) {
+			t.Errorf("Log(%q, %d) parsed %+v", test.revRange, test.limit, commits[0])
+		}
+	}
+
+	// AddNote commits to the notes ref as whoever runs it
+	t.Setenv("GIT_COMMITTER_NAME", "jot")
+	t.Setenv("GIT_COMMITTER_EMAIL", "jot@example.com")
+	t.Setenv("GIT_AUTHOR_NAME", "jot")
+	t.Setenv("GIT_AUTHOR_EMAIL", "jot@example.com")
+	for _, message := range []string{"jot: a", "jot: b", "jot: a"} {
+		if err := AddNote(repo, head, message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	out, err := exec.Command("git", "-C", repo, "notes", "--ref="+NotesRef, "show", head).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Fields(strings.ReplaceAll(string(out), "jot: ", "")); strings.Join(got, " ") != "a b" {
+		t.Errorf("git note = %q, want a and b once each", out)
+	}
+}
@@ -0,0 +1,314 @@
+// Package gitctx resolves the repository context (project and branch) that
+// notes are filed under.
+package gitctx
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// State is the condition of HEAD when the context was resolved.
+type State int
+
+const (
+	// StateBranch means HEAD points at a branch.
+	StateBranch State = iota
+	// StateDetached means HEAD points directly at a commit.
+	StateDetached
+	// StateRebasing means a rebase is in progress; Branch is the branch
+	// being rebased.
+	StateRebasing
+	// StateBisecting means a bisect is in progress; Branch is the branch
+	// the bisect started from.
+	StateBisecting
+	// StateNotRepo means jot was not run inside a git repository.
+	StateNotRepo
+)
+
+// GlobalProject is the project notes are filed under outside a repository.
+const GlobalProject = "global"
+
+// Policies for filing notes when HEAD is detached.
+const (
+	// DetachedGlobal files notes project-wide (the default).
+	DetachedGlobal = "global"
+	// DetachedPrompt asks for a branch name.
+	DetachedPrompt = "prompt"
+	// DetachedRevision files notes under the tag or short commit.
+	DetachedRevision = "revision"
+)
+
+// Context describes where jot was invoked from.
+type Context struct {
+	State State
+	// Root is the top level of the current working tree. For a linked
+	// worktree this is the worktree directory, not the main checkout.
+	Root string
+	// CommonDir is the git directory shared by every worktree of the
+	// repository.
+	CommonDir string
+	// GitDir is the git directory of the current worktree.
+	GitDir string
+
+	Project string
+	// SubProject is the part of a monorepo the working directory is in, as
+	// configured in the repository's .jot.json.
+	SubProject string
+	// Branch is the checked out branch, or the branch being rebased or
+	// bisected. It is empty when HEAD is detached or outside a repository.
+	Branch string
+	// Ticket is the issue key found in Branch, such as ABC-123 in
+	// "feature/ABC-123-retry-uploads".
+	Ticket string
+	// Commit is the abbreviated SHA of HEAD.
+	Commit string
+	// Tag is the tag HEAD points at exactly, if any.
+	Tag string
+	// Onto is the branch (or commit) a rebase is replaying onto.
+	Onto string
+
+	// Parent is the project of the superproject when Root is a submodule,
+	// empty otherwise.
+	Parent string
+}
+
+// IsRepo reports whether the context was resolved inside a git repository.
+func (c Context) IsRepo() bool {
+	return c.State != StateNotRepo
+}
+
+// Revision names the detached HEAD, preferring a tag over the commit.
+func (c Context) Revision() string {
+	if c.Tag != "" {
+		return c.Tag
+	}
+	return c.Commit
+}
+
+// ticketPattern matches issue keys like ABC-123 in branch names.
+var ticketPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9])([A-Z][A-Z0-9]+-[0-9]+)(?:$|[^0-9])`)
+
+// Scope is the project and branch a note is filed under. A Branch of "*"
+// marks a project-wide note.
+type Scope struct {
+	Project    string
+	SubProject string
+	Branch     string
+}
+
+// Label names the project and sub-project, e.g. "monorepo/billing".
+func (s Scope) Label() string {
+	if s.SubProject == "" {
+		return s.Project
+	}
+	return s.Project + "/" + s.SubProject
+}
+
+// Scope returns where notes created from this context should be filed.
+// Rebases and bisects use the branch they started from, and directories
+// outside a repository use the global scope. A detached HEAD follows policy;
+// ok is false when the policy is DetachedPrompt and the caller has to ask for
+// a branch, in which case the project-wide scope is returned as a default.
+func (c Context) Scope(policy string) (scope Scope, ok bool) {
+	switch c.State {
+	case StateNotRepo:
+		return Scope{Project: GlobalProject, Branch: "*"}, true
+	case StateDetached:
+		scope := Scope{Project: c.Project, SubProject: c.SubProject, Branch: "*"}
+		switch policy {
+		case DetachedPrompt:
+			return scope, false
+		case DetachedRevision:
+			if rev := c.Revision(); rev != "" {
+				scope.Branch = rev
+			}
+		}
+		return scope, true
+	}
+	return Scope{Project: c.Project, SubProject: c.SubProject, Branch: c.Branch}, true
+}
+
+// TicketFor returns the ticket of notes filed under scope: the ticket in the
+// current branch name when scope is that branch, empty otherwise.
+func (c Context) TicketFor(scope Scope) string {
+	if scope.Branch != c.Branch || scope.Project != c.Project {
+		return ""
+	}
+	return c.Ticket
+}
+
+// String describes the context for display, e.g. "jot on main",
+// "jot detached at v1.2.0 (3f2c1ab)" or, in a submodule, "lib (in app) on
+// main".
+func (c Context) String() string {
+	project := Scope{Project: c.Project, SubProject: c.SubProject}.Label()
+	if c.Parent != "" {
+		project += " (in " + c.Parent + ")"
+	}
+	switch c.State {
+	case StateNotRepo:
+		return "not a git repository"
+	case StateDetached:
+		if c.Tag != "" {
+			return fmt.Sprintf("%s detached at %s (%s)", project, c.Tag, c.Commit)
+		}
+		return fmt.Sprintf("%s detached at %s", project, c.Commit)
+	case StateRebasing:
+		if c.Onto != "" {
+			return fmt.Sprintf("%s rebasing %s onto %s", project, c.Branch, c.Onto)
+		}
+		return fmt.Sprintf("%s rebasing %s", project, c.Branch)
+	case StateBisecting:
+		return fmt.Sprintf("%s bisecting from %s", project, c.Branch)
+	}
+	return fmt.Sprintf("%s on %s", project, c.Branch)
+}
+
+// Resolve inspects the repository containing dir (the working directory when
+// dir is empty). All worktrees of a repository resolve to the same project,
+// while a submodule is its own project with Parent pointing at the
+// superproject.
+func Resolve(dir string) Context {
+	if dir == "" {
+		if cwd, err := os.Getwd(); err == nil {
+			dir = cwd
+		}
+	}
+
+	notRepo := Context{State: StateNotRepo, Project: GlobalProject}
+	out, err := git(dir, "rev-parse", "--path-format=absolute",
+		"--show-toplevel", "--git-common-dir", "--absolute-git-dir",
+		"--show-superproject-working-tree")
+	if err != nil {
+		return notRepo
+	}
+
+	lines := strings.Split(out, "\n")
+	if len(lines) < 3 || lines[0] == "" {
+		// Inside a bare repository or the .git directory itself
+		return notRepo
+	}
+
+	var ctx Context
+	ctx.Root = lines[0]
+	ctx.CommonDir = lines[1]
+	ctx.GitDir = lines[2]
+	if len(lines) > 3 && lines[3] != "" {
+		ctx.Parent = Resolve(lines[3]).Project
+	}
+
+	ctx.Project = projectName(ctx)
+	if realDir, err := filepath.EvalSymlinks(dir); err == nil {
+		ctx.SubProject = subProject(ctx.Root, realDir)
+	}
+	resolveHead(&ctx)
+	if m := ticketPattern.FindStringSubmatch(ctx.Branch); m != nil {
+		ctx.Ticket = m[1]
+	}
+	return ctx
+}
+
+// projectName derives the project from the origin remote, falling back to the
+// directory of the main worktree so that every worktree shares a name.
+func projectName(ctx Context) string {
+	if url, err := git(ctx.Root, "remote", "get-url", "origin"); err == nil {
+		// Extract project name from URL (handle both SSH and HTTPS)
+		url = strings.TrimSuffix(url, "/")
+		if i := strings.LastIndexAny(url, "/:"); i >= 0 {
+			url = url[i+1:]
+		}
+		if name := strings.TrimSuffix(url, ".git"); name != "" {
+			return name
+		}
+	}
+
+	// The common dir of a regular repository is <main worktree>/.git. For
+	// submodules it lives under the superproject's .git/modules, so the
+	// submodule's own top level is the better name.
+	if filepath.Base(ctx.CommonDir) == ".git" {
+		return filepath.Base(filepath.Dir(ctx.CommonDir))
+	}
+	return filepath.Base(ctx.Root)
+}
+
+// resolveHead fills in the state, branch and revision of HEAD.
+func resolveHead(ctx *Context) {
+	// Fails on an unborn branch, which still has a branch name below
+	ctx.Commit, _ = git(ctx.Root, "rev-parse", "--short", "HEAD")
+
+	if branch, err := git(ctx.Root, "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		ctx.State = StateBranch
+		ctx.Branch = branch
+		return
+	}
+
+	for _, dir := range []string{"rebase-merge", "rebase-apply"} {
+		headName := readGitFile(ctx.GitDir, dir, "head-name")
+		if headName == "" {
+			continue
+		}
+		// Rebasing a detached HEAD has no branch to follow
+		if !strings.HasPrefix(headName, "refs/heads/") {
+			break
+		}
+		ctx.State = StateRebasing
+		ctx.Branch = strings.TrimPrefix(headName, "refs/heads/")
+		if onto := readGitFile(ctx.GitDir, dir, "onto"); onto != "" {
+			ctx.Onto = describeCommit(ctx.Root, onto)
+		}
+		return
+	}
+
+	// BISECT_START holds the branch the bisect started from, or the SHA
+	// when it started on a detached HEAD
+	start := readGitFile(ctx.GitDir, "BISECT_START")
+	if _, err := git(ctx.Root, "show-ref", "--verify", "--quiet", "refs/heads/"+start); start != "" && err == nil {
+		ctx.State = StateBisecting
+		ctx.Branch = start
+		return
+	}
+
+	ctx.State = StateDetached
+	ctx.Tag, _ = git(ctx.Root, "describe", "--tags", "--exact-match", "HEAD")
+}
+
+// describeCommit names a commit after a local branch pointing at it, falling
+// back to the abbreviated SHA.
+func describeCommit(root, sha string) string {
+	name, err := git(root, "name-rev", "--name-only", "--no-undefined",
+		"--refs=refs/heads/*", sha)
+	if err == nil && !strings.ContainsAny(name, "~^") {
+		return name
+	}
+	if short, err := git(root, "rev-parse", "--short", sha); err == nil {
+		return short
+	}
+	return sha
+}
+
+func readGitFile(gitDir string, elem ...string) string {
+	data, err := os.ReadFile(filepath.Join(append([]string{gitDir}, elem...)...))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func git(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("git %s: %s", args[0], strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
@@ -0,0 +1,71 @@
+package gitctx
+
+import (
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// run runs a git command in dir, failing the test when it fails
+func run(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(cmd.Environ(),
+		"GIT_AUTHOR_NAME=jot", "GIT_AUTHOR_EMAIL=jot@example.com",
+		"GIT_COMMITTER_NAME=jot", "GIT_COMMITTER_EMAIL=jot@example.com",
+		"GIT_CONFIG_GLOBAL=/dev/null", "GIT_ALLOW_PROTOCOL=file")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestResolveSubmodule(t *testing.T) {
+	dir := t.TempDir()
+	lib, app := filepath.Join(dir, "lib"), filepath.Join(dir, "app")
+	for _, repo := range []string{lib, app} {
+		run(t, dir, "init", "-q", "-b", "main", repo)
+		run(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	}
+	run(t, app, "-c", "protocol.file.allow=always", "submodule", "add", "-q", lib, "lib")
+
+	ctx := Resolve(filepath.Join(app, "lib"))
+	if ctx.Project != "lib" || ctx.Parent != "app" {
+		t.Errorf("Project, Parent = %q, %q, want lib, app", ctx.Project, ctx.Parent)
+	}
+	if got, want := ctx.String(), "lib (in app) on main"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+	if parent := Resolve(app); parent.Parent != "" || parent.String() != "app on main" {
+		t.Errorf("superproject resolved as %q with Parent %q", parent.String(), parent.Parent)
+	}
+}
+
+func TestResolveBisect(t *testing.T) {
+	tests := []struct {
+		name   string
+		detach bool
+		state  State
+		branch string
+	}{
+		{"from a branch", false, StateBisecting, "main"},
+		{"from a detached HEAD", true, StateDetached, ""},
+	}
+	for _, test := range tests {
+		repo := t.TempDir()
+		run(t, repo, "init", "-q", "-b", "main")
+		for _, message := range []string{"one", "two", "three"} {
+			run(t, repo, "commit", "-q", "--allow-empty", "-m", message)
+		}
+		if test.detach {
+			run(t, repo, "checkout", "-q", "--detach")
+		}
+		run(t, repo, "bisect", "start", "HEAD", "HEAD~2")
+
+		ctx := Resolve(repo)
+		if ctx.State != test.state || ctx.Branch != test.branch {
+			t.Errorf("%s: State, Branch = %v, %q, want %v, %q",
+				test.name, ctx.State, ctx.Branch, test.state, test.branch)
+		}
+	}
+}
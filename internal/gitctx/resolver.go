@@ -0,0 +1,83 @@
+package gitctx
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Resolver provides the context notes are filed under. Commands and the TUI
+// take a Resolver rather than running git themselves so that they can be
+// given a fixed context.
+type Resolver interface {
+	Context() Context
+}
+
+// Static is a Resolver that always returns the same context.
+type Static Context
+
+func (s Static) Context() Context {
+	return Context(s)
+}
+
+// CachedResolver resolves the context of a directory once and reuses it
+// until HEAD of the worktree changes, so repeated lookups cost a stat rather
+// than several git processes.
+type CachedResolver struct {
+	dir string
+
+	mu       sync.Mutex
+	ctx      Context
+	resolved bool
+	headPath string
+	headMod  time.Time
+}
+
+// NewResolver returns a CachedResolver for dir, the working directory when
+// dir is empty.
+func NewResolver(dir string) *CachedResolver {
+	return &CachedResolver{dir: dir}
+}
+
+func (r *CachedResolver) Context() Context {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.resolved && !r.headChanged() {
+		return r.ctx
+	}
+
+	r.ctx = Resolve(r.dir)
+	r.resolved = true
+	r.headPath = ""
+	if r.ctx.GitDir != "" {
+		r.headPath = filepath.Join(r.ctx.GitDir, "HEAD")
+		r.headMod = modTime(r.headPath)
+	}
+	return r.ctx
+}
+
+// Invalidate forces the next Context call to resolve again.
+func (r *CachedResolver) Invalidate() {
+	r.mu.Lock()
+	r.resolved = false
+	r.mu.Unlock()
+}
+
+// headChanged reports whether HEAD was rewritten since the context was
+// resolved, which covers checkouts, detaching and starting a rebase.
+func (r *CachedResolver) headChanged() bool {
+	if r.headPath == "" {
+		return false
+	}
+	return !modTime(r.headPath).Equal(r.headMod)
+}
+
+func modTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,49 @@
+package gitctx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCachedResolverFollowsHead(t *testing.T) {
+	repo := t.TempDir()
+	run(t, repo, "init", "-q", "-b", "main")
+	run(t, repo, "commit", "-q", "--allow-empty", "-m", "init")
+	run(t, repo, "branch", "other")
+	head := filepath.Join(repo, ".git", "HEAD")
+	at := time.Now().Add(-time.Hour)
+	// setHead points HEAD at branch, with the given modification time
+	setHead := func(branch string, modTime time.Time) {
+		if err := os.WriteFile(head, []byte("ref: refs/heads/"+branch+"\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(head, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	setHead("main", at)
+
+	var resolver Resolver = NewResolver(repo)
+	tests := []struct {
+		name   string
+		change func()
+		want   string
+	}{
+		{"first lookup", func() {}, "main"},
+		// Same time: only a cached context would still say main
+		{"HEAD unchanged", func() { setHead("other", at) }, "main"},
+		{"HEAD rewritten", func() { setHead("other", at.Add(time.Second)) }, "other"},
+		{"invalidated", func() {
+			setHead("main", at.Add(time.Second))
+			resolver.(*CachedResolver).Invalidate()
+		}, "main"},
+	}
+	for _, test := range tests {
+		test.change()
+		if got := resolver.Context().Branch; got != test.want {
+			t.Errorf("%s: Branch = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package gitctx
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/JonLD/jot/internal/config"
+)
+
+// subProject maps dir to a sub-project using the repository's path patterns.
+// When several patterns match, the one matching the most directories wins,
+// then the one with the most literal segments, then the first in lexical
+// order, so the result never depends on map order.
+func subProject(root, dir string) string {
+	repoCfg, err := config.LoadRepo(root)
+	if err != nil || len(repoCfg.SubProjects) == 0 {
+		return ""
+	}
+
+	rel, err := filepath.Rel(root, dir)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return ""
+	}
+	rel = filepath.ToSlash(rel)
+
+	best, bestPattern, bestDepth, bestLiterals := "", "", 0, 0
+	for pattern, name := range repoCfg.SubProjects {
+		matched, depth := matchPrefix(pattern, rel)
+		if depth == 0 {
+			continue
+		}
+		literals := literalSegments(pattern)
+		better := bestDepth == 0 || depth > bestDepth ||
+			(depth == bestDepth && literals > bestLiterals) ||
+			(depth == bestDepth && literals == bestLiterals && pattern < bestPattern)
+		if better {
+			if name == "" {
+				name = matched
+			}
+			best, bestPattern, bestDepth, bestLiterals = name, pattern, depth, literals
+		}
+	}
+	return best
+}
+
+// literalSegments counts the pattern segments without wildcards
+func literalSegments(pattern string) int {
+	count := 0
+	for _, segment := range strings.Split(strings.Trim(pattern, "/"), "/") {
+		if !strings.ContainsAny(segment, `*?[\`) {
+			count++
+		}
+	}
+	return count
+}
+
+// matchPrefix reports whether rel lies inside pattern. Each pattern segment
+// is matched with path.Match and a trailing "**" matches anything below, so
+// "services/billing" and "services/billing/**" are equivalent. It returns the
+// last directory matched and how many directories matched, zero meaning rel
+// is outside pattern. Patterns with segments after "**" are invalid and
+// match nothing.
+func matchPrefix(pattern, rel string) (string, int) {
+	var dirs []string
+	if rel != "." {
+		dirs = strings.Split(rel, "/")
+	}
+
+	segments := strings.Split(strings.Trim(pattern, "/"), "/")
+	for i, segment := range segments {
+		if segment == "**" && i != len(segments)-1 {
+			return "", 0
+		}
+	}
+
+	matched, depth := "", 0
+	for i, segment := range segments {
+		if segment == "**" {
+			break
+		}
+		if i >= len(dirs) {
+			return "", 0
+		}
+		if ok, _ := path.Match(segment, dirs[i]); !ok {
+			return "", 0
+		}
+		matched, depth = dirs[i], i+1
+	}
+	return matched, depth
+}
@@ -0,0 +1,67 @@
+package gitctx
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatchPrefix(t *testing.T) {
+	tests := []struct {
+		pattern, rel string
+		matched      string
+		depth        int
+	}{
+		{"services/billing/**", "services/billing", "billing", 2},
+		{"services/billing/**", "services/billing/api/v1", "billing", 2},
+		{"services/billing", "services/billing/api", "billing", 2},
+		{"services/*/**", "services/auth/cmd", "auth", 2},
+		{"/services/*/", "services/auth", "auth", 2},
+		{"services/*/**", "services", "", 0},
+		{"services/*/**", "libs/auth", "", 0},
+		{"services/billing/**", ".", "", 0},
+		{"**", "services", "", 0},
+		{"services/**/api", "services/billing/api", "", 0},
+	}
+	for _, test := range tests {
+		matched, depth := matchPrefix(test.pattern, test.rel)
+		if matched != test.matched || depth != test.depth {
+			t.Errorf("matchPrefix(%q, %q) = %q, %d, want %q, %d",
+				test.pattern, test.rel, matched, depth, test.matched, test.depth)
+		}
+	}
+}
+
+func TestSubProject(t *testing.T) {
+	root := t.TempDir()
+	config := `{"subprojects": {
+		"services/*/**": "",
+		"services/billing/**": "billing",
+		"libs/[ab]*/**": "ab",
+		"libs/a*/**": "a",
+		"libs/**/x": "bad"
+	}}`
+	if err := os.WriteFile(filepath.Join(root, ".jot.json"), []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		dir, want string
+	}{
+		{"services/billing/api", "billing"},
+		{"services/auth", "auth"},
+		// Both have one literal segment, so lexical order decides
+		{"libs/alpha", "ab"},
+		{"libs/beta", "ab"},
+		{"libs/other/x", ""},
+		{"docs", ""},
+	}
+	for _, test := range tests {
+		// Run each case several times, since map order varies between runs
+		for range 20 {
+			if got := subProject(root, filepath.Join(root, test.dir)); got != test.want {
+				t.Fatalf("subProject(%q) = %q, want %q", test.dir, got, test.want)
+			}
+		}
+	}
+}
@@ -1,15 +1,81 @@
 package storage
 
-import "time"
+import (
+    "fmt"
+    "time"
+
+    "github.com/JonLD/jot/internal/gitctx"
+)
+
+// Pin is where a note is pinned to the top of the TUI list
+type Pin string
+
+const (
+    // PinNone leaves the note in its sorted place
+    PinNone Pin = ""
+    // PinProject pins the note in its own project's lists
+    PinProject Pin = "project"
+    // PinGlobal pins the note in every list
+    PinGlobal Pin = "global"
+)
 
 type Note struct {
     ID        string
     Title     string
     Path      string
     Project   string
+    SubProject string
     Branch    string
     Ticket    string
     Tags      []string
+    Archived  bool
     CreatedAt time.Time
     ModifiedAt time.Time
+    OpenCount int
+    LastOpenedAt time.Time
+    Pinned    Pin
+}
+
+// Frecency ranks notes by how often and how recently they were opened: the
+// open count, weighted by the age of the last open
+func (n *Note) Frecency(now time.Time) float64 {
+    if n.OpenCount == 0 {
+        return 0
+    }
+    const day = 24 * time.Hour
+    age := now.Sub(n.LastOpenedAt)
+    weight := 10.0
+    switch {
+    case age < 4*day:
+        weight = 100
+    case age < 14*day:
+        weight = 70
+    case age < 31*day:
+        weight = 50
+    case age < 90*day:
+        weight = 30
+    }
+    return float64(n.OpenCount) * weight
+}
+
+// GitNoteMessage is the pointer to the note jot stores in refs/notes/jot
+func (n *Note) GitNoteMessage() string {
+    return fmt.Sprintf("jot: %s (%s) %s", n.Title, n.ID, n.Path)
+}
+
+// PinnedIn reports whether the note is pinned in lists of the given scope's
+// project
+func (n *Note) PinnedIn(scope gitctx.Scope) bool {
+    switch n.Pinned {
+    case PinGlobal:
+        return true
+    case PinProject:
+        return n.Project == scope.Project && n.SubProject == scope.SubProject
+    }
+    return false
+}
+
+// Scope returns the project, sub-project and branch the note is filed under
+func (n *Note) Scope() gitctx.Scope {
+    return gitctx.Scope{Project: n.Project, SubProject: n.SubProject, Branch: n.Branch}
 }
@@ -0,0 +1,176 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// headerKeys are the metadata lines jot writes at the top of a note, in order
+var headerKeys = []string{"Project", "Subproject", "Branch", "Ticket"}
+
+// defaultNotePath is where jot files a note:
+// ~/.jot/notes/project/[subproject/][ticket/]branch/title.md, or
+// ~/.jot/notes/project/[subproject/]title.md for project-wide notes
+func defaultNotePath(note *Note) (string, error) {
+	var logicalPath string
+	if note.Branch == "*" {
+		logicalPath = filepath.Join(note.Project, note.SubProject)
+	} else if note.Ticket != "" {
+		logicalPath = filepath.Join(note.Project, note.SubProject, note.Ticket, note.Branch)
+	} else {
+		logicalPath = filepath.Join(note.Project, note.SubProject, note.Branch)
+	}
+
+	// Get notes directory (same as database directory)
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	notesDir := filepath.Join(homeDir, ".jot", "notes", logicalPath)
+	return filepath.Join(notesDir, note.Title+".md"), nil
+}
+
+// noteHeader is the title and metadata block of a new note
+func noteHeader(note *Note) string {
+	header := fmt.Sprintf("# %s\n\nCreated: %s\n",
+		note.Title, note.CreatedAt.Format("2006-01-02 15:04:05"))
+	for _, line := range headerLines(note) {
+		header += line + "\n"
+	}
+	return header
+}
+
+func headerLines(note *Note) []string {
+	lines := []string{"Project: " + note.Project}
+	if note.SubProject != "" {
+		lines = append(lines, "Subproject: "+note.SubProject)
+	}
+	lines = append(lines, "Branch: "+note.Branch)
+	if note.Ticket != "" {
+		lines = append(lines, "Ticket: "+note.Ticket)
+	}
+	return lines
+}
+
+// syncNoteFile keeps a note's file in step with metadata changes. A file at
+// its default location moves to the new default location, and the header's
+// title and metadata lines are rewritten. Files placed elsewhere stay put.
+func syncNoteFile(previous, note *Note) error {
+	if previous.Title == note.Title && previous.Project == note.Project &&
+		previous.SubProject == note.SubProject && previous.Branch == note.Branch &&
+		previous.Ticket == note.Ticket {
+		return nil
+	}
+
+	if note.Path == previous.Path {
+		oldPath, err := defaultNotePath(previous)
+		if err != nil {
+			return err
+		}
+		newPath, err := defaultNotePath(note)
+		if err != nil {
+			return err
+		}
+		if previous.Path == oldPath && newPath != oldPath {
+			if _, err := os.Stat(newPath); err == nil {
+				return fmt.Errorf("a note already exists at %s", newPath)
+			}
+			if err := os.MkdirAll(filepath.Dir(newPath), 0755); err != nil {
+				return err
+			}
+			if err := os.Rename(oldPath, newPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to move file: %v", err)
+			}
+			note.Path = newPath
+		}
+	}
+
+	content, err := os.ReadFile(note.Path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	updated := rewriteHeader(string(content), previous, note)
+	if updated == string(content) {
+		return nil
+	}
+	return os.WriteFile(note.Path, []byte(updated), 0644)
+}
+
+// rewriteHeader updates the header above the first "---" line: the heading
+// when it still shows the old title, and the metadata lines, which are
+// replaced where the first of them was found (or added after the creation
+// date when they were all removed)
+func rewriteHeader(content string, previous, note *Note) string {
+	lines := strings.Split(content, "\n")
+	end := -1
+	for i, line := range lines {
+		if strings.TrimSpace(line) == "---" {
+			end = i
+			break
+		}
+	}
+	if end < 0 {
+		return content
+	}
+
+	var header []string
+	insertAt, createdAt := -1, -1
+	for _, line := range lines[:end] {
+		if line == "# "+previous.Title {
+			line = "# " + note.Title
+		}
+		if isMetadataLine(line) {
+			if insertAt < 0 {
+				insertAt = len(header)
+			}
+			continue
+		}
+		header = append(header, line)
+		if strings.HasPrefix(line, "Created:") {
+			createdAt = len(header)
+		}
+	}
+	if insertAt < 0 {
+		insertAt = createdAt
+	}
+	if insertAt >= 0 {
+		metadata := headerLines(note)
+		header = append(header[:insertAt], append(metadata, header[insertAt:]...)...)
+	}
+	return strings.Join(append(header, lines[end:]...), "\n")
+}
+
+// copyContent is a note's content for a copy of it: the header rewritten
+// for the copy's title and scope, and dated when the copy was made. Content
+// without a header gets a new one above it.
+func copyContent(content string, source, note *Note) string {
+	lines := strings.Split(rewriteHeader(content, source, note), "\n")
+	end := slices.IndexFunc(lines, func(line string) bool {
+		return strings.TrimSpace(line) == "---"
+	})
+	if end < 0 {
+		// Without a header to rewrite, give the copy a fresh one
+		return noteHeader(note) + "\n---\n\n" + content
+	}
+	for i := range end {
+		if strings.HasPrefix(lines[i], "Created:") {
+			lines[i] = "Created: " + note.CreatedAt.Format("2006-01-02 15:04:05")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func isMetadataLine(line string) bool {
+	for _, key := range headerKeys {
+		if strings.HasPrefix(line, key+":") {
+			return true
+		}
+	}
+	return false
+}
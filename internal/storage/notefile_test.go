@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRewriteHeader(t *testing.T) {
+	previous := &Note{Title: "Old", Project: "p", Branch: "main"}
+	note := &Note{Title: "New", Project: "q", SubProject: "api", Branch: "dev", Ticket: "ABC-1"}
+	tests := []struct {
+		name, content, want string
+	}{
+		{
+			"heading and metadata",
+			"# Old\n\nCreated: 2024-01-01 10:00:00\nProject: p\nBranch: main\n\n---\n\nbody\n",
+			"# New\n\nCreated: 2024-01-01 10:00:00\nProject: q\nSubproject: api\nBranch: dev\nTicket: ABC-1\n\n---\n\nbody\n",
+		},
+		{
+			"renamed heading kept",
+			"# Mine\nProject: p\n---\n# Old\n",
+			"# Mine\nProject: q\nSubproject: api\nBranch: dev\nTicket: ABC-1\n---\n# Old\n",
+		},
+		{
+			"metadata removed",
+			"# Old\nCreated: 2024-01-01 10:00:00\nnotes\n---\n",
+			"# New\nCreated: 2024-01-01 10:00:00\nProject: q\nSubproject: api\nBranch: dev\nTicket: ABC-1\nnotes\n---\n",
+		},
+		{"no separator", "# Old\nProject: p\n", "# Old\nProject: p\n"},
+	}
+	for _, test := range tests {
+		if got := rewriteHeader(test.content, previous, note); got != test.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestCopyContent(t *testing.T) {
+	source := &Note{Title: "Plan", Project: "p", Branch: "main"}
+	copied := &Note{Title: "Plan (copy)", Project: "p", Branch: "main",
+		CreatedAt: time.Date(2025, 3, 4, 5, 6, 7, 0, time.Local)}
+	tests := []struct {
+		name, content, want string
+	}{
+		{
+			"header",
+			"# Plan\n\nCreated: 2024-01-01 10:00:00\nProject: p\nBranch: main\n\n---\n\nCreated: keep me\n",
+			"# Plan (copy)\n\nCreated: 2025-03-04 05:06:07\nProject: p\nBranch: main\n\n---\n\nCreated: keep me\n",
+		},
+		{
+			"no header",
+			"# Plan\nProject: p\nbody\n",
+			"# Plan (copy)\n\nCreated: 2025-03-04 05:06:07\nProject: p\nBranch: main\n\n---\n\n# Plan\nProject: p\nbody\n",
+		},
+	}
+	for _, test := range tests {
+		if got := copyContent(test.content, source, copied); got != test.want {
+			t.Errorf("%s: got\n%q\nwant\n%q", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package storage
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "regexp"
+    "slices"
+    "sort"
+    "strconv"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/sahilm/fuzzy"
+)
+
+// Query is a search such as "api bug project:api -tag:wip modified:<7d":
+// qualifiers every note must satisfy, plus free text matched against titles
+// (or contents, in the TUI's content search)
+type Query struct {
+    Text    string
+    Filters []QueryFilter
+}
+
+// QueryFilter is one "key:value" qualifier, negated by a leading "-"
+type QueryFilter struct {
+    Key    string
+    Value  string
+    Negate bool
+    match  func(note *Note) bool
+}
+
+// queryKeys are the qualifiers ParseQuery recognizes. Other words with a
+// colon are free text.
+var queryKeys = []string{"project", "branch", "ticket", "tag", "is", "modified", "created"}
+
+var openTodoPattern = regexp.MustCompile(`(?m)^\s*([-*+]|\d+\.)\s+\[ \]`)
+
+// ParseQuery splits a search into qualifiers and free text. Qualifiers that
+// don't parse are left out of the query and reported together in the error,
+// so a half-typed search still filters by the rest.
+func ParseQuery(search string) (Query, error) {
+    var query Query
+    var words []string
+    var errs []error
+    now := time.Now()
+
+    for _, word := range strings.Fields(search) {
+        negate := strings.HasPrefix(word, "-")
+        key, value, found := strings.Cut(strings.TrimPrefix(word, "-"), ":")
+        key = strings.ToLower(key)
+        if !found || !slices.Contains(queryKeys, key) {
+            words = append(words, word)
+            continue
+        }
+        filter, err := newQueryFilter(key, value, now)
+        if err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        filter.Negate = negate
+        query.Filters = append(query.Filters, filter)
+    }
+    query.Text = strings.Join(words, " ")
+    return query, errors.Join(errs...)
+}
+
+func newQueryFilter(key, value string, now time.Time) (QueryFilter, error) {
+    filter := QueryFilter{Key: key, Value: value}
+    if value == "" {
+        return filter, fmt.Errorf("missing value for %s:", key)
+    }
+
+    switch key {
+    case "project":
+        filter.match = func(note *Note) bool {
+            return strings.EqualFold(note.Project, value) ||
+                strings.EqualFold(note.Scope().Label(), value)
+        }
+    case "branch":
+        filter.match = func(note *Note) bool {
+            return strings.EqualFold(note.Branch, value)
+        }
+    case "ticket":
+        filter.match = func(note *Note) bool {
+            return strings.EqualFold(note.Ticket, value)
+        }
+    case "tag":
+        filter.match = func(note *Note) bool {
+            return slices.ContainsFunc(note.Tags, func(tag string) bool {
+                return strings.EqualFold(tag, value)
+            })
+        }
+    case "is":
+        switch strings.ToLower(value) {
+        case "todo":
+            filter.match = hasOpenTodo
+        default:
+            return filter, fmt.Errorf("invalid value for is: %s (expected todo)", value)
+        }
+    case "modified", "created":
+        match, err := timeMatcher(value, now)
+        if err != nil {
+            return filter, fmt.Errorf("invalid value for %s: %s (%v)", key, value, err)
+        }
+        filter.match = func(note *Note) bool {
+            if key == "created" {
+                return match(note.CreatedAt)
+            }
+            return match(note.ModifiedAt)
+        }
+    }
+    return filter, nil
+}
+
+// timeMatcher parses an age such as "<7d", ">2w" or "12h" (younger or older
+// than; no operator means younger), or a date such as "<2024-01-31",
+// ">2024-01-31" or "2024-01-31" (before, after or on that day)
+func timeMatcher(value string, now time.Time) (func(time.Time) bool, error) {
+    operator := ""
+    if strings.HasPrefix(value, "<") || strings.HasPrefix(value, ">") {
+        operator, value = value[:1], value[1:]
+    }
+
+    if day, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+        next := day.AddDate(0, 0, 1)
+        switch operator {
+        case "<":
+            return func(t time.Time) bool { return t.Before(day) }, nil
+        case ">":
+            return func(t time.Time) bool { return !t.Before(next) }, nil
+        }
+        return func(t time.Time) bool { return !t.Before(day) && t.Before(next) }, nil
+    }
+
+    if len(value) < 2 {
+        return nil, errors.New("expected an age like 7d or a date like 2024-01-31")
+    }
+    count, err := strconv.Atoi(value[:len(value)-1])
+    if err != nil || count < 0 {
+        return nil, errors.New("expected an age like 7d or a date like 2024-01-31")
+    }
+    var unit time.Duration
+    switch value[len(value)-1] {
+    case 'h':
+        unit = time.Hour
+    case 'd':
+        unit = 24 * time.Hour
+    case 'w':
+        unit = 7 * 24 * time.Hour
+    default:
+        return nil, errors.New("ages are in h, d or w")
+    }
+    cutoff := now.Add(-time.Duration(count) * unit)
+    if operator == ">" {
+        return func(t time.Time) bool { return t.Before(cutoff) }, nil
+    }
+    return func(t time.Time) bool { return t.After(cutoff) }, nil
+}
+
+// todoCache holds hasOpenTodo's answers by note ID, each valid while the
+// file's size and modification time are unchanged, so is:todo doesn't read
+// every file on each keystroke. Content searches ask from their own
+// goroutine, hence the lock.
+var todoCache = struct {
+    sync.Mutex
+    entries map[string]todoEntry
+}{entries: make(map[string]todoEntry)}
+
+type todoEntry struct {
+    modTime time.Time
+    size    int64
+    open    bool
+}
+
+// hasOpenTodo reports whether the note's file has an unchecked checkbox
+func hasOpenTodo(note *Note) bool {
+    info, err := os.Stat(note.Path)
+    if err != nil {
+        return false
+    }
+    todoCache.Lock()
+    entry, ok := todoCache.entries[note.ID]
+    todoCache.Unlock()
+    if ok && entry.modTime.Equal(info.ModTime()) && entry.size == info.Size() {
+        return entry.open
+    }
+
+    content, err := os.ReadFile(note.Path)
+    if err != nil {
+        return false
+    }
+    open := openTodoPattern.Match(content)
+    todoCache.Lock()
+    todoCache.entries[note.ID] = todoEntry{modTime: info.ModTime(), size: info.Size(), open: open}
+    todoCache.Unlock()
+    return open
+}
+
+// PruneTodoCache forgets the is:todo answers of notes not in notes, for
+// callers to run when they reload the notes
+func PruneTodoCache(notes []*Note) {
+    keep := make(map[string]bool, len(notes))
+    for _, note := range notes {
+        keep[note.ID] = true
+    }
+    todoCache.Lock()
+    defer todoCache.Unlock()
+    for id := range todoCache.entries {
+        if !keep[id] {
+            delete(todoCache.entries, id)
+        }
+    }
+}
+
+// String is the qualifier as typed, e.g. "-tag:wip"
+func (filter QueryFilter) String() string {
+    prefix := ""
+    if filter.Negate {
+        prefix = "-"
+    }
+    return prefix + filter.Key + ":" + filter.Value
+}
+
+// Matches reports whether the note satisfies every qualifier. The free text
+// is left to the caller.
+func (query Query) Matches(note *Note) bool {
+    for _, filter := range query.Filters {
+        if filter.match(note) == filter.Negate {
+            return false
+        }
+    }
+    return true
+}
+
+// Filter returns the notes matching the qualifiers whose titles fuzzy match
+// the text, best match first and the most frecent among equal matches.
+// Without text the notes keep their order.
+func (query Query) Filter(notes []*Note) []*Note {
+    var candidates []*Note
+    for _, note := range notes {
+        if query.Matches(note) {
+            candidates = append(candidates, note)
+        }
+    }
+    if query.Text == "" {
+        return candidates
+    }
+
+    titles := make([]string, len(candidates))
+    for i, note := range candidates {
+        titles[i] = note.Title
+    }
+    matches := fuzzy.Find(query.Text, titles)
+    now := time.Now()
+    sort.SliceStable(matches, func(i, j int) bool {
+        if matches[i].Score != matches[j].Score {
+            return matches[i].Score > matches[j].Score
+        }
+        return candidates[matches[i].Index].Frecency(now) > candidates[matches[j].Index].Frecency(now)
+    })
+    var matched []*Note
+    for _, match := range matches {
+        matched = append(matched, candidates[match.Index])
+    }
+    return matched
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+    "time"
+)
+
+func TestParseQuery(t *testing.T) {
+    tests := []struct {
+        search  string
+        text    string
+        filters []string
+        wantErr bool
+    }{
+        {"api bug", "api bug", nil, false},
+        {"api project:jot -tag:wip", "api", []string{"project:jot", "-tag:wip"}, false},
+        {"Branch:main is:todo", "", []string{"branch:main", "is:todo"}, false},
+        {"modified:<7d created:2024-01-31", "", []string{"modified:<7d", "created:2024-01-31"}, false},
+        {"http://example.com", "http://example.com", nil, false},
+        {"fix tag: is:done", "fix", nil, true},
+        {"modified:soon ticket:ABC-1", "", []string{"ticket:ABC-1"}, true},
+    }
+    for _, test := range tests {
+        query, err := ParseQuery(test.search)
+        if (err != nil) != test.wantErr {
+            t.Errorf("ParseQuery(%q) error = %v, want error %v", test.search, err, test.wantErr)
+        }
+        if query.Text != test.text {
+            t.Errorf("ParseQuery(%q) text = %q, want %q", test.search, query.Text, test.text)
+        }
+        var filters []string
+        for _, filter := range query.Filters {
+            filters = append(filters, filter.String())
+        }
+        if len(filters) != len(test.filters) {
+            t.Errorf("ParseQuery(%q) filters = %v, want %v", test.search, filters, test.filters)
+            continue
+        }
+        for i := range filters {
+            if filters[i] != test.filters[i] {
+                t.Errorf("ParseQuery(%q) filters = %v, want %v", test.search, filters, test.filters)
+                break
+            }
+        }
+    }
+}
+
+func TestQueryMatches(t *testing.T) {
+    note := &Note{Project: "mono", SubProject: "billing", Branch: "main", Ticket: "ABC-1", Tags: []string{"Perf"}}
+    tests := []struct {
+        search string
+        want   bool
+    }{
+        {"project:mono", true},
+        {"project:mono/billing", true},
+        {"project:other", false},
+        {"-project:other", true},
+        {"tag:perf branch:MAIN", true},
+        {"tag:perf -ticket:abc-1", false},
+    }
+    for _, test := range tests {
+        query, err := ParseQuery(test.search)
+        if err != nil {
+            t.Fatal(err)
+        }
+        if got := query.Matches(note); got != test.want {
+            t.Errorf("%q matches = %v, want %v", test.search, got, test.want)
+        }
+    }
+}
+
+func TestTimeMatcher(t *testing.T) {
+    now := time.Date(2024, 2, 10, 12, 0, 0, 0, time.Local)
+    tests := []struct {
+        value string
+        at    time.Time
+        want  bool
+    }{
+        {"<7d", now.AddDate(0, 0, -3), true},
+        {"<7d", now.AddDate(0, 0, -8), false},
+        {"7d", now.AddDate(0, 0, -3), true},
+        {">2w", now.AddDate(0, 0, -15), true},
+        {">2w", now.AddDate(0, 0, -13), false},
+        {"12h", now.Add(-11 * time.Hour), true},
+        {"2024-01-31", time.Date(2024, 1, 31, 23, 59, 0, 0, time.Local), true},
+        {"2024-01-31", time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local), false},
+        {"<2024-01-31", time.Date(2024, 1, 30, 12, 0, 0, 0, time.Local), true},
+        {"<2024-01-31", time.Date(2024, 1, 31, 0, 0, 0, 0, time.Local), false},
+        {">2024-01-31", time.Date(2024, 2, 1, 0, 0, 0, 0, time.Local), true},
+        {">2024-01-31", time.Date(2024, 1, 31, 23, 0, 0, 0, time.Local), false},
+    }
+    for _, test := range tests {
+        match, err := timeMatcher(test.value, now)
+        if err != nil {
+            t.Errorf("timeMatcher(%q): %v", test.value, err)
+            continue
+        }
+        if got := match(test.at); got != test.want {
+            t.Errorf("timeMatcher(%q)(%v) = %v, want %v", test.value, test.at, got, test.want)
+        }
+    }
+
+    for _, value := range []string{"", "d", "-1d", "7y", "2024-13-01", "<"} {
+        if _, err := timeMatcher(value, now); err == nil {
+            t.Errorf("timeMatcher(%q) succeeded, want an error", value)
+        }
+    }
+}
+
+func TestHasOpenTodoCachesUntilFileChanges(t *testing.T) {
+    path := filepath.Join(t.TempDir(), "note.md")
+    modTime := time.Now().Add(-time.Hour)
+    write := func(content string, at time.Time) {
+        if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+        if err := os.Chtimes(path, at, at); err != nil {
+            t.Fatal(err)
+        }
+    }
+    note := &Note{ID: "todo-cache", Path: path}
+
+    write("# Note\n\n- [ ] ship it\n", modTime)
+    if !hasOpenTodo(note) {
+        t.Fatal("open checkbox not found")
+    }
+    // Same size and time: only a cached answer would still say open
+    write("# Note\n\n- [x] ship it\n", modTime)
+    if !hasOpenTodo(note) {
+        t.Error("answer not cached while the file is unchanged")
+    }
+    // A quick edit keeps the note's ModifiedAt, but not the file's time
+    write("# Note\n\n- [x] ship it\n", modTime.Add(time.Millisecond))
+    if hasOpenTodo(note) {
+        t.Error("cached answer used after the file changed")
+    }
+
+    PruneTodoCache(nil)
+    todoCache.Lock()
+    defer todoCache.Unlock()
+    if _, ok := todoCache.entries[note.ID]; ok {
+        t.Error("pruned note still cached")
+    }
+}
@@ -7,9 +7,23 @@ CREATE TABLE IF NOT EXISTS notes (
 	title TEXT NOT NULL,
 	path TEXT,
 	project TEXT,
+	subproject TEXT,
 	branch TEXT,
 	ticket TEXT,
 	tags TEXT,
+	archived INTEGER NOT NULL DEFAULT 0,
 	created_at DATETIME,
-	modified_at DATETIME
-);
\ No newline at end of file
+	modified_at DATETIME,
+	open_count INTEGER NOT NULL DEFAULT 0,
+	last_opened_at DATETIME,
+	pinned TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS note_commits (
+	note_id TEXT NOT NULL,
+	sha TEXT NOT NULL,
+	linked_at DATETIME,
+	PRIMARY KEY (note_id, sha)
+);
+
+CREATE INDEX IF NOT EXISTS idx_note_commits_sha ON note_commits (sha);
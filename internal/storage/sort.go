@@ -0,0 +1,107 @@
+package storage
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+    "time"
+)
+
+// SortMode is an ordering of notes in listings
+type SortMode string
+
+const (
+    SortFrecency SortMode = "frecency"
+    SortModified SortMode = "modified"
+    SortCreated  SortMode = "created"
+    SortTitle    SortMode = "title"
+    SortScope    SortMode = "scope"
+)
+
+// SortModes lists every sort mode in the order the TUI cycles through them
+var SortModes = []SortMode{SortFrecency, SortModified, SortCreated, SortTitle, SortScope}
+
+// DefaultSort is the order of the TUI and jot list until one is chosen
+const DefaultSort = SortFrecency
+
+// ParseSortMode validates a sort mode name, defaulting to DefaultSort when
+// name is empty
+func ParseSortMode(name string) (SortMode, error) {
+    if name == "" {
+        return DefaultSort, nil
+    }
+    for _, mode := range SortModes {
+        if string(mode) == name {
+            return mode, nil
+        }
+    }
+    return DefaultSort, fmt.Errorf("invalid sort mode: %s", name)
+}
+
+// Next returns the mode after m in SortModes, wrapping around
+func (m SortMode) Next() SortMode {
+    for i, mode := range SortModes {
+        if mode == m {
+            return SortModes[(i+1)%len(SortModes)]
+        }
+    }
+    return SortModes[0]
+}
+
+// Description names the mode for headers and help text
+func (m SortMode) Description() string {
+    switch m {
+    case SortFrecency:
+        return "frecency"
+    case SortCreated:
+        return "created"
+    case SortTitle:
+        return "title"
+    case SortScope:
+        return "project/branch"
+    }
+    return "modified"
+}
+
+// SortNotes orders notes in place. Frecency puts the most used first, then
+// the newest; modified and created put the newest first; scope orders by
+// project, sub-project, branch and then title.
+func SortNotes(notes []*Note, mode SortMode) {
+    less := func(a, b *Note) bool {
+        return a.ModifiedAt.After(b.ModifiedAt)
+    }
+    switch mode {
+    case SortFrecency:
+        now := time.Now()
+        less = func(a, b *Note) bool {
+            if scoreA, scoreB := a.Frecency(now), b.Frecency(now); scoreA != scoreB {
+                return scoreA > scoreB
+            }
+            return a.ModifiedAt.After(b.ModifiedAt)
+        }
+    case SortCreated:
+        less = func(a, b *Note) bool {
+            return a.CreatedAt.After(b.CreatedAt)
+        }
+    case SortTitle:
+        less = func(a, b *Note) bool {
+            return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+        }
+    case SortScope:
+        less = func(a, b *Note) bool {
+            if a.Project != b.Project {
+                return a.Project < b.Project
+            }
+            if a.SubProject != b.SubProject {
+                return a.SubProject < b.SubProject
+            }
+            if a.Branch != b.Branch {
+                return a.Branch < b.Branch
+            }
+            return strings.ToLower(a.Title) < strings.ToLower(b.Title)
+        }
+    }
+    sort.SliceStable(notes, func(i, j int) bool {
+        return less(notes[i], notes[j])
+    })
+}
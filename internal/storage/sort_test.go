@@ -0,0 +1,63 @@
+package storage
+
+import (
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestSortNotes(t *testing.T) {
+    now := time.Now()
+    day := 24 * time.Hour
+    notes := []*Note{
+        {ID: "a", Title: "beta", Project: "web", Branch: "main",
+            CreatedAt: now.Add(-3 * day), ModifiedAt: now.Add(-1 * day)},
+        {ID: "b", Title: "Alpha", Project: "api", SubProject: "v2", Branch: "dev",
+            CreatedAt: now.Add(-1 * day), ModifiedAt: now.Add(-3 * day),
+            OpenCount: 2, LastOpenedAt: now.Add(-40 * day)},
+        {ID: "c", Title: "gamma", Project: "api", Branch: "main",
+            CreatedAt: now.Add(-2 * day), ModifiedAt: now.Add(-2 * day),
+            OpenCount: 1, LastOpenedAt: now.Add(-1 * day)},
+        {ID: "d", Title: "delta", Project: "api", Branch: "main",
+            CreatedAt: now.Add(-4 * day), ModifiedAt: now.Add(-4 * day)},
+    }
+    tests := []struct {
+        mode SortMode
+        want string
+    }{
+        // c scores 100, b 60; a and d are unopened, newest first
+        {SortFrecency, "c b a d"},
+        {SortModified, "a c b d"},
+        {SortCreated, "b c a d"},
+        {SortTitle, "b a d c"},
+        {SortScope, "d c b a"},
+    }
+    for _, test := range tests {
+        sorted := append([]*Note(nil), notes...)
+        SortNotes(sorted, test.mode)
+        ids := make([]string, len(sorted))
+        for i, note := range sorted {
+            ids[i] = note.ID
+        }
+        if got := strings.Join(ids, " "); got != test.want {
+            t.Errorf("SortNotes(%s) = %s, want %s", test.mode, got, test.want)
+        }
+    }
+}
+
+func TestParseSortMode(t *testing.T) {
+    if mode, err := ParseSortMode(""); err != nil || mode != SortFrecency {
+        t.Errorf(`ParseSortMode("") = %q, %v, want frecency`, mode, err)
+    }
+    for _, mode := range SortModes {
+        if parsed, err := ParseSortMode(string(mode)); err != nil || parsed != mode {
+            t.Errorf("ParseSortMode(%q) = %q, %v", mode, parsed, err)
+        }
+    }
+    if _, err := ParseSortMode("size"); err == nil {
+        t.Error(`ParseSortMode("size") succeeded, want an error`)
+    }
+    if next := SortScope.Next(); next != SortModes[0] {
+        t.Errorf("SortScope.Next() = %q, want %q", next, SortModes[0])
+    }
+}
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"fmt"
 	"time"
 	"database/sql"
@@ -9,6 +10,7 @@ import (
 	"encoding/json"
 	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
 	_ "embed"
 
@@ -49,6 +51,10 @@ func NewSQLiteStore(dbPath string, cfg *config.Config) (*SQLiteStore, error) {
 		return nil, fmt.Errorf("failed to execute schema: %v", err)
 	}
 
+	if err := migrate(db); err != nil {
+		return nil, fmt.Errorf("failed to migrate schema: %v", err)
+	}
+
 	return &SQLiteStore{
 		db: db,
 		cfg: cfg,
@@ -58,33 +64,32 @@ func NewSQLiteStore(dbPath string, cfg *config.Config) (*SQLiteStore, error) {
 type SQLiteStore struct {
 	db *sql.DB
 	cfg *config.Config
+	// watch is a dedicated connection for DataVersion, which is reported
+	// per connection
+	watch *sql.Conn
 }
 
 func (store *SQLiteStore) Create(note Note) (*Note, error) {
+	return store.create(note, func(note *Note) string {
+		return noteHeader(note) + "\n---\n\n"
+	})
+}
+
+// create files a new note with the file content made by content, once the
+// note has its ID, dates and path. The file is written before the row is
+// inserted, and removed again if the insert fails.
+func (store *SQLiteStore) create(note Note, content func(note *Note) string) (*Note, error) {
 	note.ID = uuid.NewString()
 	note.CreatedAt = time.Now()
 	note.ModifiedAt = time.Now()
 
 	// Build the file path if not provided
 	if note.Path == "" {
-		// Create logical path: project/ticket/branch/ or project/branch/
-		var logicalPath string
-		if note.Branch == "*" {
-			logicalPath = filepath.Join(note.Project)
-		} else if note.Ticket != "" {
-			logicalPath = filepath.Join(note.Project, note.Ticket, note.Branch)
-		} else {
-			logicalPath = filepath.Join(note.Project, note.Branch)
-		}
-
-
-		// Get notes directory (same as database directory)
-		homeDir, err := os.UserHomeDir()
+		path, err := defaultNotePath(&note)
 		if err != nil {
 			return nil, err
 		}
-		notesDir := filepath.Join(homeDir, ".jot", "notes", logicalPath)
-		note.Path = filepath.Join(notesDir, note.Title+".md")
+		note.Path = path
 	}
 
 	// Create the directory structure
@@ -93,31 +98,24 @@ func (store *SQLiteStore) Create(note Note) (*Note, error) {
 		return nil, err
 	}
 
-	// Create the markdown file with basic content
-	content := fmt.Sprintf("# %s\n\nCreated: %s\nProject: %s\nBranch: %s\n",
-		note.Title, note.CreatedAt.Format("2006-01-02 15:04:05"), note.Project, note.Branch)
-	if note.Ticket != "" {
-		content += fmt.Sprintf("Ticket: %s\n", note.Ticket)
-	}
-	content += "\n---\n\n"
-
-	if err := os.WriteFile(note.Path, []byte(content), 0644); err != nil {
-		return nil, err
-	}
-
 	// Convert tags slice to JSON string
 	tagsJSON, err := json.Marshal(note.Tags)
 	if err != nil {
 		return nil, err
 	}
 
+	if err := os.WriteFile(note.Path, []byte(content(&note)), 0644); err != nil {
+		return nil, err
+	}
+
 	_, err = store.db.Exec(`
-		INSERT INTO notes (id, title, path, project, branch, ticket, tags, created_at, modified_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
-		note.ID, note.Title, note.Path, note.Project, note.Branch, note.Ticket,
-		string(tagsJSON), note.CreatedAt, note.ModifiedAt)
+		INSERT INTO notes (id, title, path, project, subproject, branch, ticket, tags, archived, created_at, modified_at, pinned)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		note.ID, note.Title, note.Path, note.Project, note.SubProject, note.Branch, note.Ticket,
+		string(tagsJSON), note.Archived, note.CreatedAt, note.ModifiedAt, note.Pinned)
 
 	if err != nil {
+		os.Remove(note.Path)
 		return nil, err
 	}
 
@@ -139,6 +137,10 @@ func (store *SQLiteStore) Delete(id string) error {
 	}
 
 	// Delete from database
+	_, err = store.db.Exec("DELETE FROM note_commits WHERE note_id = ?", id)
+	if err != nil {
+		return err
+	}
 	_, err = store.db.Exec("DELETE FROM notes WHERE id = ?", id)
 	return err
 }
@@ -149,11 +151,16 @@ func (store *SQLiteStore) Update(id string, opts ...UpdateOption) (*Note, error)
 		return nil, err
 	}
 
+	previous := *note
 	for _, opt := range opts {
 		opt(note)
 	}
 	note.ModifiedAt = time.Now()
 
+	if err := syncNoteFile(&previous, note); err != nil {
+		return nil, err
+	}
+
 	tagsJSON, err := json.Marshal(note.Tags);
 	if err != nil {
 		return nil, err
@@ -161,119 +168,228 @@ func (store *SQLiteStore) Update(id string, opts ...UpdateOption) (*Note, error)
 
 	_, err = store.db.Exec(`
 		UPDATE notes
-		SET title = ?, path = ?, project = ?, branch = ?, ticket = ?, tags = ?, modified_at = ?
+		SET title = ?, path = ?, project = ?, subproject = ?, branch = ?, ticket = ?, tags = ?,
+			archived = ?, modified_at = ?, pinned = ?
 		WHERE id = ?`,
-		note.Title, note.Path, note.Project, note.Branch, note.Ticket,
-		string(tagsJSON), note.ModifiedAt, id)
+		note.Title, note.Path, note.Project, note.SubProject, note.Branch, note.Ticket,
+		string(tagsJSON), note.Archived, note.ModifiedAt, note.Pinned, id)
 
 	if err != nil {
+		// Move the file back to where the row still points
+		restored := previous
+		restored.Path = note.Path
+		if undoErr := syncNoteFile(note, &restored); undoErr != nil {
+			return nil, fmt.Errorf("%w (and restoring the file failed: %v)", err, undoErr)
+		}
 		return nil, err
 	}
 	return note, nil
 }
 
-func (store *SQLiteStore) GetByID(id string) (*Note, error) {
-	var note Note
-	var tagsJSON string
-
-	err := store.db.QueryRow(`
-		SELECT id, title, path, project, branch, ticket, tags, created_at, modified_at
-		FROM notes WHERE id = ?`, id).Scan(
-		&note.ID, &note.Title, &note.Path, &note.Project, &note.Branch,
-		&note.Ticket, &tagsJSON, &note.CreatedAt, &note.ModifiedAt)
-
+func (store *SQLiteStore) Copy(id string, opts ...UpdateOption) (*Note, error) {
+	source, err := store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	content, err := os.ReadFile(source.Path)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("note with id %s not found", id)
-		}
 		return nil, err
 	}
 
-	// Unmarshal tags JSON
-	err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
+	note := Note{
+		Title:      source.Title,
+		Project:    source.Project,
+		SubProject: source.SubProject,
+		Branch:     source.Branch,
+		Ticket:     source.Ticket,
+		Tags:       slices.Clone(source.Tags),
+	}
+	for _, opt := range opts {
+		opt(&note)
+	}
+	if note.Scope() == source.Scope() && note.Title == source.Title {
+		note.Title += " (copy)"
+	}
+	// create writes the file unconditionally, so don't let it replace
+	// another note's
+	path, err := defaultNotePath(&note)
 	if err != nil {
 		return nil, err
 	}
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("a note already exists at %s", path)
+	}
 
-	return &note, nil
+	return store.create(note, func(note *Note) string {
+		return copyContent(string(content), source, note)
+	})
 }
 
-func (store *SQLiteStore) GetAll() ([]*Note, error) {
-	rows, err := store.db.Query(`
-		SELECT id, title, path, project, branch, ticket, tags, created_at, modified_at
-		FROM notes`)
+func (store *SQLiteStore) Append(id string, text string) (*Note, error) {
+	note, err := store.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
-	defer rows.Close()
 
-	var notes []*Note
-	for rows.Next() {
-		var note Note
-		var tagsJSON string
+	content, err := os.ReadFile(note.Path)
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > 0 && !strings.HasSuffix(string(content), "\n") {
+		text = "\n" + text
+	}
+	if !strings.HasSuffix(text, "\n") {
+		text += "\n"
+	}
+	file, err := os.OpenFile(note.Path, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.WriteString(text); err != nil {
+		file.Close()
+		return nil, err
+	}
+	if err := file.Close(); err != nil {
+		return nil, err
+	}
 
-		err := rows.Scan(&note.ID, &note.Title, &note.Path, &note.Project,
-			&note.Branch, &note.Ticket, &tagsJSON, &note.CreatedAt, &note.ModifiedAt)
-		if err != nil {
-			return nil, err
-		}
+	note.ModifiedAt = time.Now()
+	_, err = store.db.Exec("UPDATE notes SET modified_at = ? WHERE id = ?", note.ModifiedAt, id)
+	if err != nil {
+		return nil, err
+	}
+	return note, nil
+}
 
-		// Unmarshal tags JSON
-		err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
-		if err != nil {
-			return nil, err
-		}
+func (store *SQLiteStore) Write(id string, content string) (*Note, error) {
+	note, err := store.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(note.Path, []byte(content), 0644); err != nil {
+		return nil, err
+	}
 
-		notes = append(notes, &note)
+	note.ModifiedAt = time.Now()
+	_, err = store.db.Exec("UPDATE notes SET modified_at = ? WHERE id = ?", note.ModifiedAt, id)
+	if err != nil {
+		return nil, err
 	}
+	return note, nil
+}
 
-	// Check for errors during iteration
-	if err = rows.Err(); err != nil {
+func (store *SQLiteStore) GetByID(id string) (*Note, error) {
+	note, err := scanNote(store.db.QueryRow(selectNotes+" WHERE id = ?", id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("note with id %s not found", id)
+		}
 		return nil, err
 	}
 
-	return notes, nil
+	return note, nil
+}
+
+func (store *SQLiteStore) GetAll() ([]*Note, error) {
+	return store.queryNotes(selectNotes)
 }
 
 func (store *SQLiteStore) GetInProject(project string) ([]*Note, error) {
+	return store.queryNotes(selectNotes+" WHERE project = ?", project)
+}
+
+func (store *SQLiteStore) GetByBranch(branch string) ([]*Note, error) {
+	return store.queryNotes(selectNotes+" WHERE branch = ?", branch)
+}
+
+func (store *SQLiteStore) GetByTicket(ticket string) ([]*Note, error) {
+	return store.queryNotes(selectNotes+" WHERE ticket = ?", ticket)
+}
+
+func (store *SQLiteStore) GetProjectMisc(project string) ([]*Note, error) {
+	return store.queryNotes(selectNotes+" WHERE project = ? AND (branch = '' OR branch IS NULL)", project)
+}
+
+// LinkCommit records that the commit with the given full SHA relates to the
+// note. Linking the same commit twice is a no-op.
+func (store *SQLiteStore) LinkCommit(id string, sha string) error {
+	if _, err := store.GetByID(id); err != nil {
+		return err
+	}
+
+	_, err := store.db.Exec(`
+		INSERT OR IGNORE INTO note_commits (note_id, sha, linked_at)
+		VALUES (?, ?, ?)`, id, sha, time.Now())
+	return err
+}
+
+// GetCommits returns the SHAs linked to a note, oldest link first.
+func (store *SQLiteStore) GetCommits(id string) ([]string, error) {
 	rows, err := store.db.Query(`
-		SELECT id, title, path, project, branch, ticket, tags, created_at, modified_at
-		FROM notes WHERE project = ?`, project)
+		SELECT sha FROM note_commits WHERE note_id = ? ORDER BY linked_at`, id)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var notes []*Note
+	var shas []string
 	for rows.Next() {
-		var note Note
-		var tagsJSON string
-
-		err := rows.Scan(&note.ID, &note.Title, &note.Path, &note.Project,
-			&note.Branch, &note.Ticket, &tagsJSON, &note.CreatedAt, &note.ModifiedAt)
-		if err != nil {
+		var sha string
+		if err := rows.Scan(&sha); err != nil {
 			return nil, err
 		}
+		shas = append(shas, sha)
+	}
 
-		err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
-		if err != nil {
-			return nil, err
-		}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return shas, nil
+}
+
+func (store *SQLiteStore) GetByCommit(sha string) ([]*Note, error) {
+	return store.queryNotes(selectNotes+`
+		WHERE id IN (SELECT note_id FROM note_commits WHERE sha = ?)`, sha)
+}
+
+// selectNotes selects every column scanNote reads. Columns added after the
+// first release are coalesced since rows written by older clients (such as
+// jot.nvim) may leave them NULL.
+const selectNotes = `
+	SELECT id, title, path, project, COALESCE(subproject, ''), branch, ticket, tags,
+		COALESCE(archived, 0), created_at, modified_at, COALESCE(open_count, 0), last_opened_at,
+		COALESCE(pinned, '')
+	FROM notes`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-		notes = append(notes, &note)
+func scanNote(row rowScanner) (*Note, error) {
+	var note Note
+	var tagsJSON string
+	var lastOpened sql.NullTime
+
+	err := row.Scan(&note.ID, &note.Title, &note.Path, &note.Project, &note.SubProject,
+		&note.Branch, &note.Ticket, &tagsJSON, &note.Archived, &note.CreatedAt, &note.ModifiedAt,
+		&note.OpenCount, &lastOpened, &note.Pinned)
+	if err != nil {
+		return nil, err
 	}
+	note.LastOpenedAt = lastOpened.Time
 
-	if err = rows.Err(); err != nil {
+	// Unmarshal tags JSON
+	err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
+	if err != nil {
 		return nil, err
 	}
 
-	return notes, nil
+	return &note, nil
 }
 
-func (store *SQLiteStore) GetByBranch(branch string) ([]*Note, error) {
-	rows, err := store.db.Query(`
-		SELECT id, title, path, project, branch, ticket, tags, created_at, modified_at
-		FROM notes WHERE branch = ?`, branch)
+func (store *SQLiteStore) queryNotes(query string, args ...any) ([]*Note, error) {
+	rows, err := store.db.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -281,23 +397,14 @@ func (store *SQLiteStore) GetByBranch(branch string) ([]*Note, error) {
 
 	var notes []*Note
 	for rows.Next() {
-		var note Note
-		var tagsJSON string
-
-		err := rows.Scan(&note.ID, &note.Title, &note.Path, &note.Project,
-			&note.Branch, &note.Ticket, &tagsJSON, &note.CreatedAt, &note.ModifiedAt)
+		note, err := scanNote(rows)
 		if err != nil {
 			return nil, err
 		}
-
-		err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
-		if err != nil {
-			return nil, err
-		}
-
-		notes = append(notes, &note)
+		notes = append(notes, note)
 	}
 
+	// Check for errors during iteration
 	if err = rows.Err(); err != nil {
 		return nil, err
 	}
@@ -305,114 +412,193 @@ func (store *SQLiteStore) GetByBranch(branch string) ([]*Note, error) {
 	return notes, nil
 }
 
-func (store *SQLiteStore) GetByTicket(ticket string) ([]*Note, error) {
-	rows, err := store.db.Query(`
-		SELECT id, title, path, project, branch, ticket, tags, created_at, modified_at
-		FROM notes WHERE ticket = ?`, ticket)
+// columnMigrations are columns added to the notes table after its first
+// release. They are also part of schema.sql for new databases.
+var columnMigrations = []struct {
+	name       string
+	definition string
+}{
+	{"subproject", "TEXT"},
+	{"archived", "INTEGER NOT NULL DEFAULT 0"},
+	{"open_count", "INTEGER NOT NULL DEFAULT 0"},
+	{"last_opened_at", "DATETIME"},
+	{"pinned", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrate adds missing columns to databases created by older versions.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(notes)")
 	if err != nil {
-		return nil, err
+		return err
 	}
 	defer rows.Close()
 
-	var notes []*Note
+	existing := make(map[string]bool)
 	for rows.Next() {
-		var note Note
-		var tagsJSON string
+		var cid, notNull, pk int
+		var name, colType string
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &defaultValue, &pk); err != nil {
+			return err
+		}
+		existing[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
 
-		err := rows.Scan(&note.ID, &note.Title, &note.Path, &note.Project,
-			&note.Branch, &note.Ticket, &tagsJSON, &note.CreatedAt, &note.ModifiedAt)
+	for _, column := range columnMigrations {
+		if existing[column.name] {
+			continue
+		}
+		_, err := db.Exec(fmt.Sprintf("ALTER TABLE notes ADD COLUMN %s %s", column.name, column.definition))
 		if err != nil {
-			return nil, err
+			return fmt.Errorf("failed to add column %s: %v", column.name, err)
 		}
+	}
+	return nil
+}
 
-		err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
+func (store *SQLiteStore) DataVersion() (int64, error) {
+	if store.watch == nil {
+		conn, err := store.db.Conn(context.Background())
 		if err != nil {
-			return nil, err
+			return 0, err
 		}
-
-		notes = append(notes, &note)
+		store.watch = conn
 	}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
-	}
+	var version int64
+	err := store.watch.QueryRowContext(context.Background(), "PRAGMA data_version").Scan(&version)
+	return version, err
+}
 
-	return notes, nil
+// Close releases the DataVersion connection and closes the database
+func (store *SQLiteStore) Close() error {
+	if store.watch != nil {
+		store.watch.Close()
+		store.watch = nil
+	}
+	return store.db.Close()
 }
 
-func (store *SQLiteStore) GetProjectMisc(project string) ([]*Note, error) {
-	rows, err := store.db.Query(`
-		SELECT id, title, path, project, branch, ticket, tags, created_at, modified_at
-		FROM notes WHERE project = ? AND (branch = '' OR branch IS NULL)`, project)
+func (store *SQLiteStore) SyncModifiedTimes() error {
+	notes, err := store.GetAll()
 	if err != nil {
-		return nil, err
+		return err
 	}
-	defer rows.Close()
 
-	var notes []*Note
-	for rows.Next() {
-		var note Note
-		var tagsJSON string
-
-		err := rows.Scan(&note.ID, &note.Title, &note.Path, &note.Project,
-			&note.Branch, &note.Ticket, &tagsJSON, &note.CreatedAt, &note.ModifiedAt)
+	for _, note := range notes {
+		info, err := os.Stat(note.Path)
 		if err != nil {
-			return nil, err
+			continue
 		}
-
-		err = json.Unmarshal([]byte(tagsJSON), &note.Tags)
+		// Creating or updating a note writes its file just before the row,
+		// so allow for the gap
+		if info.ModTime().Sub(note.ModifiedAt) < time.Second {
+			continue
+		}
+		_, err = store.db.Exec("UPDATE notes SET modified_at = ? WHERE id = ?", info.ModTime(), note.ID)
 		if err != nil {
-			return nil, err
+			return err
 		}
-
-		notes = append(notes, &note)
 	}
+	return nil
+}
 
-	if err = rows.Err(); err != nil {
-		return nil, err
+func (store *SQLiteStore) Open(id string) error {
+	cmd, foreground, err := store.EditCommand(id, 0)
+	if err != nil {
+		return err
+	}
+	if err := store.RecordOpen(id); err != nil {
+		return err
 	}
+	if !foreground {
+		return cmd.Start()
+	}
+	// Run in foreground (current terminal)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
 
-	return notes, nil
+// RecordOpen counts an open of the note for frecency ranking. Opening a
+// note doesn't change its modified time.
+func (store *SQLiteStore) RecordOpen(id string) error {
+	result, err := store.db.Exec(`
+		UPDATE notes SET open_count = COALESCE(open_count, 0) + 1, last_opened_at = ?
+		WHERE id = ?`, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	if count, err := result.RowsAffected(); err == nil && count == 0 {
+		return fmt.Errorf("note with id %s not found", id)
+	}
+	return nil
 }
 
-func (store *SQLiteStore) Open(id string) error {
-	note, err := store.GetByID(id)
+// SetPinned pins or unpins the note, leaving its modified time alone so
+// pinning doesn't reorder the modified sort
+func (store *SQLiteStore) SetPinned(id string, pin Pin) error {
+	result, err := store.db.Exec("UPDATE notes SET pinned = ? WHERE id = ?", pin, id)
 	if err != nil {
 		return err
 	}
+	if count, err := result.RowsAffected(); err == nil && count == 0 {
+		return fmt.Errorf("note with id %s not found", id)
+	}
+	return nil
+}
 
-	var cmd *exec.Cmd
+// EditCommand returns the command that opens a note in the configured
+// editor, and whether it runs in the foreground, taking over the terminal,
+// or in the background. A line above zero puts the cursor on that line in
+// editors known to accept one.
+func (store *SQLiteStore) EditCommand(id string, line int) (*exec.Cmd, bool, error) {
+	note, err := store.GetByID(id)
+	if err != nil {
+		return nil, false, err
+	}
 
 	// Use custom editor if configured
 	if store.cfg.Editor != "" {
 		// Split the editor command to handle arguments
 		parts := strings.Fields(store.cfg.Editor)
-		if len(parts) > 1 {
-			cmd = exec.Command(parts[0], append(parts[1:], note.Path)...)
-		} else {
-			cmd = exec.Command(parts[0], note.Path)
-		}
+		cmd := exec.Command(parts[0], append(parts[1:], editorArgs(parts[0], note.Path, line)...)...)
 
 		// Run in foreground (default) or background based on config
-		if store.cfg.EditorBackground {
-			return cmd.Start()
-		} else {
-			// Run in foreground (current terminal)
-			cmd.Stdin = os.Stdin
-			cmd.Stdout = os.Stdout
-			cmd.Stderr = os.Stderr
-			return cmd.Run()
-		}
-	} else {
-		// Fall back to system default (always background)
-		switch runtime.GOOS {
-		case "windows":
-			cmd = exec.Command("cmd", "/c", "start", "", note.Path)
-		case "darwin":
-			cmd = exec.Command("open", note.Path)
-		default: // linux
-			cmd = exec.Command("xdg-open", note.Path)
-		}
-		return cmd.Start()
+		return cmd, !store.cfg.EditorBackground, nil
 	}
+
+	// Fall back to system default (always background)
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "windows":
+		cmd = exec.Command("cmd", "/c", "start", "", note.Path)
+	case "darwin":
+		cmd = exec.Command("open", note.Path)
+	default: // linux
+		cmd = exec.Command("xdg-open", note.Path)
+	}
+	return cmd, false, nil
+}
+
+// editorArgs are the arguments that open path in editor, at line when the
+// editor is known to take one
+func editorArgs(editor string, path string, line int) []string {
+	if line <= 0 {
+		return []string{path}
+	}
+	switch strings.TrimSuffix(filepath.Base(editor), ".exe") {
+	case "vi", "vim", "nvim", "gvim", "nano", "emacs", "emacsclient", "micro", "kak":
+		return []string{fmt.Sprintf("+%d", line), path}
+	case "code", "codium", "cursor":
+		return []string{"--goto", fmt.Sprintf("%s:%d", path, line)}
+	case "hx", "subl", "zed":
+		return []string{fmt.Sprintf("%s:%d", path, line)}
+	}
+	return []string{path}
 }
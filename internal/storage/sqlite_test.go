@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/JonLD/jot/internal/config"
+)
+
+// newTestStore opens a store with its database and notes under a
+// temporary home directory
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	store, err := NewSQLiteStore(filepath.Join(home, "notes.db"), &config.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestUpdateRestoresFileWhenRowFails(t *testing.T) {
+	store := newTestStore(t)
+	note, err := store.Create(Note{Title: "old", Project: "p", Branch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.db.Exec(`CREATE TRIGGER fail BEFORE UPDATE ON notes
+		BEGIN SELECT RAISE(ABORT, 'update failed'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Update(note.ID, WithTitle("new")); err == nil {
+		t.Fatal("Update succeeded, want the trigger's error")
+	}
+	content, err := os.ReadFile(note.Path)
+	if err != nil {
+		t.Fatalf("file is gone from the path the row holds: %v", err)
+	}
+	if !strings.HasPrefix(string(content), "# old\n") {
+		t.Errorf("header not restored:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(note.Path), "new.md")); !os.IsNotExist(err) {
+		t.Errorf("renamed file left behind")
+	}
+}
+
+func TestSetPinnedKeepsModifiedTime(t *testing.T) {
+	store := newTestStore(t)
+	note, err := store.Create(Note{Title: "note", Project: "p", Branch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	before, err := store.GetByID(note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.SetPinned(note.ID, PinProject); err != nil {
+		t.Fatal(err)
+	}
+	after, err := store.GetByID(note.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Pinned != PinProject {
+		t.Errorf("Pinned = %q, want %q", after.Pinned, PinProject)
+	}
+	if !after.ModifiedAt.Equal(before.ModifiedAt) {
+		t.Errorf("ModifiedAt changed from %v to %v", before.ModifiedAt, after.ModifiedAt)
+	}
+	if err := store.SetPinned("missing", PinNone); err == nil {
+		t.Errorf("SetPinned on a missing note succeeded")
+	}
+}
+
+func TestCopyLeavesNothingWhenInsertFails(t *testing.T) {
+	store := newTestStore(t)
+	source, err := store.Create(Note{Title: "plan", Project: "p", Branch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = store.db.Exec(`CREATE TRIGGER fail BEFORE INSERT ON notes
+		BEGIN SELECT RAISE(ABORT, 'insert failed'); END`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Copy(source.ID, WithBranch("dev")); err == nil {
+		t.Fatal("Copy succeeded, want the trigger's error")
+	}
+	notes, err := store.GetAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(notes) != 1 {
+		t.Errorf("%d notes after a failed copy, want 1", len(notes))
+	}
+	path, err := defaultNotePath(&Note{Title: "plan", Project: "p", Branch: "dev"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("copy's file left at %s", path)
+	}
+}
+
+func TestCloseReleasesWatchConnection(t *testing.T) {
+	store := newTestStore(t)
+	if _, err := store.DataVersion(); err != nil {
+		t.Fatal(err)
+	}
+	if store.db.Stats().OpenConnections == 0 {
+		t.Fatal("DataVersion holds no connection")
+	}
+	if err := store.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if open := store.db.Stats().OpenConnections; open != 0 {
+		t.Errorf("%d connections open after Close", open)
+	}
+}
+
+func TestEditCommand(t *testing.T) {
+	store := newTestStore(t)
+	note, err := store.Create(Note{Title: "note", Project: "p", Branch: "main"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := note.Path
+	tests := []struct {
+		editor string
+		line   int
+		want   []string
+	}{
+		{"nvim", 0, []string{"nvim", path}},
+		{"nvim", 12, []string{"nvim", "+12", path}},
+		{"/usr/bin/vim -u NONE", 3, []string{"/usr/bin/vim", "-u", "NONE", "+3", path}},
+		{"code --wait", 7, []string{"code", "--wait", "--goto", path + ":7"}},
+		{"hx", 2, []string{"hx", path + ":2"}},
+		{"ed", 5, []string{"ed", path}},
+	}
+	for _, test := range tests {
+		store.cfg.Editor = test.editor
+		cmd, foreground, err := store.EditCommand(note.ID, test.line)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !foreground || strings.Join(cmd.Args, " ") != strings.Join(test.want, " ") {
+			t.Errorf("EditCommand with %q at line %d = %q (foreground %v), want %q",
+				test.editor, test.line, cmd.Args, foreground, test.want)
+		}
+	}
+	if _, _, err := store.EditCommand("missing", 1); err == nil {
+		t.Error("EditCommand on a missing note succeeded")
+	}
+}
@@ -1,16 +1,47 @@
 package storage
 
+import "os/exec"
+
 type NoteStore interface {
     Create(note Note) (*Note, error)
     Open(id string) error
+    // EditCommand is the editor command Open runs, and whether it takes
+    // over the terminal. A line above zero opens the note at that line.
+    EditCommand(id string, line int) (*exec.Cmd, bool, error)
+    // RecordOpen counts an open of the note, made by Open or by callers
+    // that open the note themselves
+    RecordOpen(id string) error
+    // SetPinned pins or unpins the note. Pinning doesn't count as
+    // modifying it.
+    SetPinned(id string, pin Pin) error
     Delete(id string) error
     Update(id string, opts ...UpdateOption) (*Note, error)
+    // Copy creates a note with the content and tags of note id, changed by
+    // opts, its header rewritten for the copy's title and scope. A copy in
+    // the same scope under the same title gets " (copy)" after it.
+    Copy(id string, opts ...UpdateOption) (*Note, error)
+    // Append adds text to the end of the note's file, on lines of its own,
+    // and counts as modifying the note
+    Append(id string, text string) (*Note, error)
+    // Write replaces the content of the note's file, and counts as
+    // modifying the note
+    Write(id string, content string) (*Note, error)
     GetByID(id string) (*Note, error)
     GetAll() ([]*Note, error)
     GetInProject(project string) ([]*Note, error)
     GetProjectMisc(project string) ([]*Note, error)
     GetByTicket(ticket string) ([]*Note, error)
     GetByBranch(branch string) ([]*Note, error)
+    LinkCommit(id string, sha string) error
+    GetCommits(id string) ([]string, error)
+    GetByCommit(sha string) ([]*Note, error)
+    // DataVersion changes whenever another process, or another connection
+    // of this one, commits a change to the store
+    DataVersion() (int64, error)
+    // SyncModifiedTimes moves each note's modified time up to its file's
+    // when the file was edited after the note was last updated
+    SyncModifiedTimes() error
+    Close() error
 }
 
 type UpdateOption func(*Note)
@@ -27,6 +58,10 @@ func WithProject(project string) UpdateOption {
     return func(n *Note) { n.Project = project }
 }
 
+func WithSubProject(subProject string) UpdateOption {
+    return func(n *Note) { n.SubProject = subProject }
+}
+
 func WithBranch(branch string) UpdateOption {
     return func(n *Note) { n.Branch = branch }
 }
@@ -38,3 +73,7 @@ func WithTicket(ticket string) UpdateOption {
 func WithTags(tags []string) UpdateOption {
     return func(n *Note) { n.Tags = tags }
 }
+
+func WithArchived(archived bool) UpdateOption {
+    return func(n *Note) { n.Archived = archived }
+}
@@ -1,75 +1,105 @@
 package ui
 
 import (
-    "log"
     "fmt"
+    "slices"
+    "sort"
     "strings"
-    "os/exec"
-    "path/filepath"
-    "os"
 
+    "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/internal/gitctx"
     "github.com/JonLD/jot/internal/storage"
-    "github.com/JonLD/jot/themes"
 
+    "github.com/charmbracelet/bubbles/help"
+    "github.com/charmbracelet/bubbles/key"
     "github.com/charmbracelet/bubbles/textinput"
+    "github.com/charmbracelet/bubbles/viewport"
     "github.com/charmbracelet/lipgloss"
     tea "github.com/charmbracelet/bubbletea"
-    "github.com/sahilm/fuzzy"
 )
 
-// Current theme TODO: add into a config file
-var currentTheme = themes.TokyoNightScheme
-
 type State int
 
 const (
     StateNormal State = iota
     StateSearch
     StateNewNote
-    StateDeleteConfirm
+    StateConfirm
+    StateBulkInput
+    StateEdit
+    StatePalette
+    StateAppend
+    StateEditor
 )
 
 type Model struct {
     Store             storage.NoteStore
+    Config            *config.Config
+    Resolver          gitctx.Resolver
+    Repo              gitctx.Context
+    Scope             gitctx.Scope
     Notes             []*storage.Note
     FilteredNotes     []*storage.Note
     DisplayedNotes     []*storage.Note
     CurrentFilter     FilterFunc
+    Sort              storage.SortMode
     Cursor            int
-    NewNoteInputText  textinput.Model
+    Offset            int
     SearchInputText   textinput.Model
+    ContentSearch     bool
+    SearchSeq         int
+    Matches           map[string]contentMatch
+    Query             storage.Query
+    QueryErr          error
     State             State
-    DeleteNoteID      string
-    DeleteNoteTitle   string
+    Selected          map[string]bool
+    VisualAnchor      int
+    Pending           BulkAction
+    BulkInputText     textinput.Model
+    ShowArchived      bool
+    Width             int
+    Height            int
+    ShowPreview       bool
+    Preview           viewport.Model
+    PreviewNoteID     string
+    TreeMode          bool
+    TreeRows          []treeRow
+    Collapsed         map[string]bool
+    Form              noteForm
+    Palette           palette
+    Append            appendForm
+    Editor            inlineEditor
+    Keys              KeyMap
+    Styles            Styles
+    Help              help.Model
+    ShowHelp          bool
+    Watch             watchState
+    LastClick         click
+    Toast             toast
 }
 
-type notesLoadedMsg struct {
-    notes []*storage.Note
-}
 
-type FilterFunc func([]*storage.Note) []*storage.Note
+// FilterFunc selects the notes to list given the scope jot was started in
+type FilterFunc func(notes []*storage.Note, scope gitctx.Scope) []*storage.Note
 
-func FilterDisplayAll(notes []*storage.Note) []*storage.Note {
+func FilterDisplayAll(notes []*storage.Note, scope gitctx.Scope) []*storage.Note {
     return notes
 }
 
-func FilterByBranch(notes []*storage.Note) []*storage.Note {
-    currentBranch := getCurrentBranch()
-    currentProject := getCurrentProject()
+func FilterByBranch(notes []*storage.Note, scope gitctx.Scope) []*storage.Note {
     var branchNotes []*storage.Note
     for _, note := range notes {
-        if note.Project == currentProject && note.Branch == currentBranch {
+        if note.Scope() == scope {
             branchNotes = append(branchNotes, note)
         }
     }
     return branchNotes
 }
 
-func FilterByProject(notes []*storage.Note) []*storage.Note {
-    currentProject := getCurrentProject()
+func FilterByProject(notes []*storage.Note, scope gitctx.Scope) []*storage.Note {
     var ProjectNotes []*storage.Note
     for _, note := range notes {
-        if note.Project == currentProject {
+        if note.Project == scope.Project && note.SubProject == scope.SubProject {
             ProjectNotes = append(ProjectNotes, note)
         }
     }
@@ -77,81 +107,115 @@ func FilterByProject(notes []*storage.Note) []*storage.Note {
 }
 
 func (model *Model) ApplyFilter(filterFunc FilterFunc) {
+    model.refreshContext()
     model.CurrentFilter = filterFunc
-    model.FilteredNotes = filterFunc(model.Notes)
-    model.DisplayedNotes = model.FilteredNotes
+    model.FilteredNotes = nil
+    for _, note := range filterFunc(model.Notes, model.Scope) {
+        if !note.Archived || model.ShowArchived {
+            model.FilteredNotes = append(model.FilteredNotes, note)
+        }
+    }
+    // Pinned notes are listed whatever the filter
+    for _, note := range model.Notes {
+        if note.PinnedIn(model.Scope) && (!note.Archived || model.ShowArchived) &&
+            !slices.Contains(model.FilteredNotes, note) {
+            model.FilteredNotes = append(model.FilteredNotes, note)
+        }
+    }
+    storage.SortNotes(model.FilteredNotes, model.Sort)
+    model.pinnedFirst(model.FilteredNotes)
+    model.setDisplayed(model.FilteredNotes)
     model.Cursor = 0
 }
 
-// Helper function to get current Git branch
-func getCurrentBranch() string {
-    cmd := exec.Command("git", "branch", "--show-current")
-    output, err := cmd.Output()
-    if err != nil {
-        return "main" // fallback
-    }
-    return strings.TrimSpace(string(output))
+// pinnedFirst moves the notes pinned in the current project to the front,
+// keeping their order otherwise
+func (model Model) pinnedFirst(notes []*storage.Note) []*storage.Note {
+    sort.SliceStable(notes, func(i, j int) bool {
+        return notes[i].PinnedIn(model.Scope) && !notes[j].PinnedIn(model.Scope)
+    })
+    return notes
 }
 
-// Helper function to get current Git project name
-func getCurrentProject() string {
-    // Try to get project name from git remote
-    cmd := exec.Command("git", "remote", "get-url", "origin")
-    output, err := cmd.Output()
-    if err == nil {
-        url := strings.TrimSpace(string(output))
-        // Extract project name from URL (handle both SSH and HTTPS)
-        if strings.Contains(url, "/") {
-            parts := strings.Split(url, "/")
-            projectName := parts[len(parts)-1]
-            // Remove .git suffix if present
-            projectName = strings.TrimSuffix(projectName, ".git")
-            if projectName != "" {
-                return projectName
-            }
-        }
+// pinnedCount is the number of pinned notes at the top of the flat list,
+// which are followed by a separator
+func (model Model) pinnedCount() int {
+    if model.TreeMode {
+        return 0
     }
-
-    // Fallback to directory name
-    cwd, err := os.Getwd()
-    if err != nil {
-        return "unknown"
+    count := 0
+    for _, note := range model.DisplayedNotes {
+        if !note.PinnedIn(model.Scope) {
+            break
+        }
+        count++
     }
-    return filepath.Base(cwd)
+    return count
 }
 
-var (
-    primaryStyle = lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.PrimaryFg))
+// hasPinSeparator reports whether a separator divides pinned notes from the
+// rest of the list
+func (model Model) hasPinSeparator() bool {
+    pinned := model.pinnedCount()
+    return pinned > 0 && pinned < len(model.DisplayedNotes)
+}
 
-    selectedStyle = lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.SelectedFg)).Bold(true)
+// cycleSort switches to the next sort mode
+func (model *Model) cycleSort() {
+    model.setSort(model.Sort.Next())
+}
 
-    mutedStyle = lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.MutedFg))
+// setSort sorts the list by mode, saves it as the default and keeps the
+// cursor on the selected note
+func (model *Model) setSort(mode storage.SortMode) {
+    model.Sort = mode
+    model.Config.Sort = string(model.Sort)
+    if err := model.Config.Save(); err != nil {
+        model.fail(fmt.Errorf("error saving sort mode: %w", err))
+    }
 
-    errorStyle = lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.ErrorFg))
+    selected := model.selectedNote()
+    storage.SortNotes(model.FilteredNotes, model.Sort)
+    model.pinnedFirst(model.FilteredNotes)
+    if model.Query.Text == "" {
+        // Fuzzy matches keep their score order, only notes picked by
+        // qualifiers alone re-sort
+        model.setDisplayed(model.Query.Filter(model.FilteredNotes))
+    }
+    if !model.TreeMode {
+        for i, note := range model.DisplayedNotes {
+            if note == selected {
+                model.Cursor = i
+            }
+        }
+    }
+}
 
-    windowStyle = lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.DefaultFg)).
-        BorderForeground(lipgloss.Color(currentTheme.BorderColor)).
-        Border(lipgloss.RoundedBorder())
 
-    popupStyle = lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.DefaultFg)).
-        BorderForeground(lipgloss.Color(currentTheme.BorderColor)).
-        Border(lipgloss.RoundedBorder())
-)
+// refreshContext picks up branch changes made while the TUI is open. The
+// resolver only runs git again when HEAD has changed.
+func (model *Model) refreshContext() {
+    model.Repo = model.Resolver.Context()
+    // The TUI cannot prompt for a branch, so a detached HEAD that should
+    // prompt gets the project-wide default
+    model.Scope, _ = model.Repo.Scope(model.Config.DetachedHead)
+}
 
-func NewModel(store storage.NoteStore, filterFunc FilterFunc) Model {
-    newNoteTextInput := textinput.New() // Creates the text input component
-    newNoteTextInput.Placeholder = "Enter note title..."
-    newNoteTextInput.CharLimit = 100
-    newNoteTextInput.Width = 40
-    newNoteTextInput.SetValue("")
-    newNoteTextInput.Focus()
-    newNoteTextInput.Blur()
+func NewModel(
+    store storage.NoteStore,
+    cfg *config.Config,
+    repo gitctx.Resolver,
+    filterFunc FilterFunc,
+) (Model, error) {
+    keys, err := NewKeyMap(cfg.KeyPreset, cfg.Keys)
+    if err != nil {
+        return Model{}, err
+    }
+    scheme, themeWarning, err := LoadTheme(cfg)
+    if err != nil {
+        return Model{}, err
+    }
+    styles := NewStyles(scheme)
 
     searchTextInput := textinput.New() // Creates the text input component
     searchTextInput.CharLimit = 100
@@ -159,300 +223,498 @@ func NewModel(store storage.NoteStore, filterFunc FilterFunc) Model {
     searchTextInput.SetValue("")
     searchTextInput.Focus()
 
+    bulkTextInput := textinput.New()
+    bulkTextInput.CharLimit = 100
+    bulkTextInput.Width = 40
 
-    return Model{
+
+    // An invalid sort in the config file falls back to the default
+    sortMode, _ := storage.ParseSortMode(cfg.Sort)
+
+    model := Model{
         Store:     store,
-        NewNoteInputText: newNoteTextInput,
+        Sort:      sortMode,
+        Config:    cfg,
+        Resolver:  repo,
         SearchInputText: searchTextInput,
+        BulkInputText: bulkTextInput,
+        Palette: palette{Input: newPaletteInput()},
+        Selected: make(map[string]bool),
+        VisualAnchor: -1,
+        Preview: viewport.New(0, 0),
+        Collapsed: make(map[string]bool),
         State: StateSearch,
         CurrentFilter: filterFunc,
+        Keys: keys,
+        Styles: styles,
+        Help: newHelp(styles),
     }
+    model.refreshContext()
+    model.fail(themeWarning)
+    return model, nil
 }
 
 func (model Model) Init() tea.Cmd {
-    return tea.Batch(
-        tea.Cmd(func() tea.Msg {
-            notes, _ := model.Store.GetAll()
-            return notesLoadedMsg{notes}
-        }),
+    cmds := []tea.Cmd{
+        model.loadNotes(),
         textinput.Blink,
-        )
+        model.poll(),
+    }
+    if model.Toast.Text != "" {
+        cmds = append(cmds, model.expireToast())
+    }
+    return tea.Batch(cmds...)
 }
 
 func (model Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+    updated, cmd := model.update(msg)
+    // Keep the tree and preview in step with the displayed notes and cursor
+    if updatedModel, ok := updated.(Model); ok {
+        updatedModel.rebuildTree()
+        updatedModel.scrollToCursor()
+        updatedModel.syncPreview()
+        if updatedModel.Toast.Seq != model.Toast.Seq {
+            cmd = tea.Batch(cmd, updatedModel.expireToast())
+        }
+        updated = updatedModel
+    }
+    return updated, cmd
+}
+
+func (model Model) update(msg tea.Msg) (tea.Model, tea.Cmd) {
     switch msg := msg.(type) {
+    case tea.WindowSizeMsg:
+        model.Width = msg.Width
+        model.Height = msg.Height
+        model.layoutPreview()
+        if model.State == StateEditor {
+            model.layoutEditor()
+        }
     case tea.KeyMsg:
+        if model.ShowHelp {
+            // Any key closes the help overlay
+            model.ShowHelp = false
+            return model, nil
+        }
         switch model.State {
-        case StateDeleteConfirm:
-            return model.updateDeleteMode(msg)
-        case StateNewNote:
-            return model.updateNewNoteMode(msg)
+        case StateConfirm:
+            return model.updateConfirmMode(msg)
+        case StateBulkInput:
+            return model.updateBulkInputMode(msg)
+        case StateNewNote, StateEdit:
+            return model.updateFormMode(msg)
+        case StatePalette:
+            return model.updatePaletteMode(msg)
+        case StateAppend:
+            return model.updateAppendMode(msg)
+        case StateEditor:
+            return model.updateEditorMode(msg)
         case StateNormal:
             return model.updateNormalMode(msg)
         case StateSearch:
             return model.updateSearchMode(msg)
         }
+    case tea.MouseMsg:
+        return model.updateMouse(msg)
+    case toastExpiredMsg:
+        if msg.seq == model.Toast.Seq {
+            model.Toast = toast{Seq: model.Toast.Seq}
+        }
     case notesLoadedMsg:
-        model.Notes = msg.notes
-        model.ApplyFilter(model.CurrentFilter)
+        return model, model.reloadNotes(msg.notes)
+    case searchTickMsg:
+        return model.updateSearchTick(msg)
+    case contentResultsMsg:
+        return model.updateContentResults(msg)
+    case pollMsg:
+        return model.updatePoll(msg)
+    case editorFinishedMsg:
+        return model.updateEditorFinished(msg)
     }
     return model, nil
 }
 
+// rowCount is the number of rows the cursor can move over
+func (model Model) rowCount() int {
+    if model.TreeMode {
+        return len(model.TreeRows)
+    }
+    return len(model.DisplayedNotes)
+}
+
 func (model Model) updateNormalMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.String() {
-    case "n":
-        model.State = StateNewNote
-        return model, model.NewNoteInputText.Focus()
-    case "i":
+    if model.TreeMode {
+        if updated, cmd, handled := model.updateTreeMode(msg); handled {
+            return updated, cmd
+        }
+    }
+
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.Help):
+        model.ShowHelp = true
+    case key.Matches(msg, keys.Palette):
+        return model.startPalette()
+    case key.Matches(msg, keys.New):
+        return model.startNewNote(model.Scope, model.Repo.TicketFor(model.Scope))
+    case key.Matches(msg, keys.Edit):
+        return model.startEdit()
+    case key.Matches(msg, keys.Copy):
+        return model.startCopy(model.Scope)
+    case key.Matches(msg, keys.Append):
+        return model.startAppend()
+    case key.Matches(msg, keys.EditInline):
+        return model.startInlineEdit()
+    case key.Matches(msg, keys.Tree):
+        model.toggleTree()
+    case key.Matches(msg, keys.Sort):
+        model.cycleSort()
+    case key.Matches(msg, keys.Search):
         model.State = StateSearch
         return model, model.SearchInputText.Focus()
 
-    case "d":
-        if len(model.Notes) > 0 && model.Cursor < len(model.Notes) {
-            selectedNote := model.Notes[model.Cursor]
-            // Set up confirmation dialog
-            model.State = StateDeleteConfirm
-            model.DeleteNoteID = selectedNote.ID
-            model.DeleteNoteTitle = selectedNote.Title
+    case key.Matches(msg, keys.Delete):
+        return model.startBulkAction(bulkDelete)
+    case key.Matches(msg, keys.Move):
+        return model.startBulkAction(bulkMove)
+    case key.Matches(msg, keys.SetTicket):
+        return model.startBulkAction(bulkSetTicket)
+    case key.Matches(msg, keys.AddTag):
+        return model.startBulkAction(bulkAddTag)
+    case key.Matches(msg, keys.RemoveTag):
+        return model.startBulkAction(bulkRemoveTag)
+    case key.Matches(msg, keys.Archive):
+        return model.startBulkAction(bulkArchive)
+    case key.Matches(msg, keys.Pin):
+        return model.togglePin()
+    case key.Matches(msg, keys.Mark):
+        if model.VisualAnchor >= 0 {
+            model.commitVisual()
+        } else if note := model.selectedNote(); note != nil {
+            if model.Selected[note.ID] {
+                delete(model.Selected, note.ID)
+            } else {
+                model.Selected[note.ID] = true
+            }
+            if model.Cursor < model.rowCount()-1 {
+                model.Cursor++
+            }
+        }
+    case key.Matches(msg, keys.MarkRange):
+        if model.VisualAnchor >= 0 {
+            model.commitVisual()
+        } else {
+            model.VisualAnchor = model.Cursor
+        }
+    case key.Matches(msg, keys.MarkAll):
+        model.toggleSelectAll()
+    case key.Matches(msg, keys.ShowArchived):
+        model.ShowArchived = !model.ShowArchived
+        model.ApplyFilter(model.CurrentFilter)
+        return model, model.applySearch()
+    case key.Matches(msg, keys.Back):
+        if model.hasSelection() {
+            model.clearSelection()
             return model, nil
         }
-    case "q", tea.KeyCtrlC.String(), tea.KeyEsc.String():
         return model, tea.Quit
-    case "j", tea.KeyDown.String():
-        if model.Cursor < len(model.Notes)-1 {
+    case key.Matches(msg, keys.Quit):
+        return model, tea.Quit
+    case key.Matches(msg, keys.Down):
+        if model.Cursor < model.rowCount()-1 {
             model.Cursor++
         }
-    case "k", tea.KeyUp.String():
+    case key.Matches(msg, keys.Up):
         if model.Cursor > 0 {
             model.Cursor--
         }
-    case tea.KeyCtrlL.String(), "enter":
-        if len(model.Notes) > 0 && model.Cursor < len(model.Notes) {
-            selectedNote := model.Notes[model.Cursor]
-            model.Store.Open(selectedNote.ID)
+    case key.Matches(msg, keys.Top):
+        model.Cursor = 0
+    case key.Matches(msg, keys.Bottom):
+        model.moveCursor(model.rowCount())
+    case key.Matches(msg, keys.PageDown):
+        model.moveCursor(model.visibleRows())
+    case key.Matches(msg, keys.PageUp):
+        model.moveCursor(-model.visibleRows())
+    case key.Matches(msg, keys.Open):
+        if selectedNote := model.selectedNote(); selectedNote != nil {
+            return model, model.openNote(selectedNote.ID)
         }
-    case "b":
+    case key.Matches(msg, keys.Preview):
+        model.togglePreview()
+    case key.Matches(msg, keys.PreviewDown):
+        model.Preview.HalfPageDown()
+    case key.Matches(msg, keys.PreviewUp):
+        model.Preview.HalfPageUp()
+    case key.Matches(msg, keys.FilterBranch):
         model.ApplyFilter(FilterByBranch)
-        return model, nil
-    case "p":
+        return model, model.applySearch()
+    case key.Matches(msg, keys.FilterProject):
         model.ApplyFilter(FilterByProject)
-        return model, nil
-    case "a":
+        return model, model.applySearch()
+    case key.Matches(msg, keys.FilterAll):
         model.ApplyFilter(FilterDisplayAll)
-        return model, nil
+        return model, model.applySearch()
     }
     return model, nil
 }
 
 func (model Model) updateSearchMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.Type {
-    case tea.KeyEsc:
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.SearchExit):
         model.State = StateNormal
         model.SearchInputText.Blur()
         return model, nil
-    case tea.KeyCtrlC:
+    case key.Matches(msg, keys.SearchQuit):
         return model, tea.Quit
-    case tea.KeyCtrlJ:
-        if model.Cursor < len(model.Notes)-1 {
+    case key.Matches(msg, keys.SearchPalette):
+        model.SearchInputText.Blur()
+        return model.startPalette()
+    case key.Matches(msg, keys.SearchDown):
+        if model.Cursor < model.rowCount()-1 {
             model.Cursor++
         }
         return model, nil
-    case tea.KeyCtrlK:
+    case key.Matches(msg, keys.SearchUp):
         if model.Cursor > 0 {
             model.Cursor--
         }
         return model, nil
-    case tea.KeyCtrlL:
-        if len(model.Notes) > 0 && model.Cursor < len(model.Notes) {
-            selectedNote := model.Notes[model.Cursor]
-            model.Store.Open(selectedNote.ID)
+    case key.Matches(msg, keys.SearchPageDown):
+        model.moveCursor(model.visibleRows())
+        return model, nil
+    case key.Matches(msg, keys.SearchPageUp):
+        model.moveCursor(-model.visibleRows())
+        return model, nil
+    case key.Matches(msg, keys.SearchOpen):
+        if selectedNote := model.selectedNote(); selectedNote != nil {
+            return model, model.openNote(selectedNote.ID)
         }
         return model, nil
-    case tea.KeyCtrlB:
-        model.ApplyFilter(FilterByBranch)
+    case key.Matches(msg, keys.SearchPreview):
+        model.togglePreview()
         return model, nil
-    case tea.KeyCtrlP:
-        model.ApplyFilter(FilterByProject)
+    case key.Matches(msg, keys.SearchPreviewDown):
+        model.Preview.HalfPageDown()
+        return model, nil
+    case key.Matches(msg, keys.SearchPreviewUp):
+        model.Preview.HalfPageUp()
         return model, nil
-    case tea.KeyCtrlA:
+    case key.Matches(msg, keys.SearchFilterBranch):
+        model.ApplyFilter(FilterByBranch)
+        return model, model.applySearch()
+    case key.Matches(msg, keys.SearchFilterProject):
+        model.ApplyFilter(FilterByProject)
+        return model, model.applySearch()
+    case key.Matches(msg, keys.SearchFilterAll):
         model.ApplyFilter(FilterDisplayAll)
+        return model, model.applySearch()
+    case key.Matches(msg, keys.SearchSort):
+        model.cycleSort()
         return model, nil
+    case key.Matches(msg, keys.SearchContent):
+        return model, model.toggleContentSearch()
     }
 
-    // First empty the filtered notes so can later append all matches
-    model.DisplayedNotes = nil
-
     var cmd tea.Cmd
     model.SearchInputText, cmd = model.SearchInputText.Update(msg)
-    var searchQuery = model.SearchInputText.Value()
-
-    if searchQuery == "" {
-        model.DisplayedNotes = model.FilteredNotes
-    } else {
-        var titles []string
-        for _, note := range model.FilteredNotes {
-            titles = append(titles, note.Title)
-        }
-        matches := fuzzy.Find(searchQuery, titles)
-        for _, match := range matches {
-            model.DisplayedNotes = append(model.DisplayedNotes, model.FilteredNotes[match.Index])
-        }
-    }
-    return model, cmd
+    return model, tea.Batch(cmd, model.applySearch())
 }
 
-func (model Model) updateNewNoteMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.Type {
-    case tea.KeyEnter, tea.KeyCtrlL:
-        title := model.NewNoteInputText.Value()
-        if title != "" {
-            newNote := storage.Note{
-                Title:   title,
-                Project: getCurrentProject(),
-                Branch:  getCurrentBranch(),
-            }
-            createdNote, err := model.Store.Create(newNote)
-            if err != nil {
-                log.Printf("Error creating note: %v", err)
-            } else {
-                model.Notes = append(model.Notes, createdNote)
-                // Open the newly created note
-                if err := model.Store.Open(createdNote.ID); err != nil {
-                    log.Printf("Error opening created note: %v", err)
-                }
-            }
-        }
-        model.State = StateNormal
-        model.NewNoteInputText.Reset()
-        model.NewNoteInputText.Blur()
-        return model, nil
+func (model *Model) togglePreview() {
+    model.ShowPreview = !model.ShowPreview
+    model.PreviewNoteID = ""
+    model.layoutPreview()
+}
 
-    case tea.KeyCtrlC, tea.KeyEsc:
-        model.State = StateNormal
-        model.NewNoteInputText.Blur()
-        model.NewNoteInputText.Reset()
-        return model, nil
+// topView is the banner, filter tabs and search bar above the list
+func (model Model) topView(width int) string {
+    var top strings.Builder
+    if !model.compact() {
+        top.WriteString(model.Styles.Primary.Bold(true).Render(banner) + "\n")
     }
+    searchBarStyle := model.Styles.Primary
 
-    var cmd tea.Cmd
-    model.NewNoteInputText, cmd = model.NewNoteInputText.Update(msg)
-    return model, cmd
+    if model.State == StateSearch {
+        searchBarStyle = searchBarStyle.Bold(true)
+    }
+
+    top.WriteString(model.tabsView() + "\n")
+    searchLabel := "Search: "
+    if model.ContentSearch {
+        searchLabel = "Search content: "
+    }
+    top.WriteString(searchBarStyle.Render(searchLabel) + model.SearchInputText.View())
+    if len(model.Query.Filters) > 0 || model.QueryErr != nil {
+        top.WriteString("\n" + model.chipsView(width))
+    }
+    return top.String()
 }
 
-func (model Model) updateDeleteMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
-    switch msg.String() {
-    case "y", "Y":
-        // Perform deletion
-        err := model.Store.Delete(model.DeleteNoteID)
-        if err != nil {
-            log.Printf("Error deleting note: %v", err)
+// noteRowView renders row i of the flat list, truncated to width, with the
+// matching line below it for content search results
+func (model Model) noteRowView(i int, width int) string {
+    row := model.noteTitleView(i, width)
+    if model.showsMatches() {
+        row += "\n"
+        if match, ok := model.Matches[model.DisplayedNotes[i].ID]; ok {
+            row += model.matchLineView(match, width)
         }
-        model.State = StateNormal
-        // Reload notes
-        cmd := tea.Cmd(func() tea.Msg {
-            notes, _ := model.Store.GetAll()
-            return notesLoadedMsg{notes}
-        })
-        return model, cmd
-    case "n", "N", "esc":
-        model.State = StateNormal
-        return model, nil
     }
-    return model, nil
+    return row
 }
 
+func (model Model) noteTitleView(i int, width int) string {
+    note := model.DisplayedNotes[i]
+    title := note.Title
+    if note.Archived {
+        title += " (archived)"
+    }
+    if model.hasSelection() {
+        title = selectionMark(model.isSelected(i, note)) + title
+    }
+    title = truncate(title, width-2)
+    if model.Cursor == i {
+        return model.Styles.Selected.Render("▶ " + title)
+    } else if model.isSelected(i, note) {
+        return model.Styles.Primary.Render("  " + title)
+    }
+    return model.Styles.Muted.Render("  " + title)
+}
+
+// helpView is the help line for the current mode
+func (model Model) helpView(width int) string {
+    keys := model.Keys
+    prefix := ""
+    bindings := keys.ShortHelp()
+    switch {
+    case model.State == StateSearch:
+        bindings = keys.searchHelp()
+    case model.hasSelection():
+        prefix = model.Styles.Muted.Render(fmt.Sprintf("%d selected · ", len(model.Selected)))
+        bindings = keys.selectionHelp()
+    case model.TreeMode:
+        bindings = keys.treeHelp()
+    }
+    // help adds one item too many when the ellipsis doesn't fit, so leave
+    // room for it and cut what still overflows
+    model.Help.Width = max(width-lipgloss.Width(prefix)-2, 1)
+    return truncate(prefix+model.Help.ShortHelpView(bindings), width)
+}
 
+// View draws the list, preview or popup above the status bar
 func (model Model) View() string {
-    listStyle := windowStyle.
-        Padding(1, 2)
+    width, _ := model.windowSize()
+    return model.windowView() + "\n" + model.statusView(width)
+}
+
+func (model Model) windowView() string {
+    width, height := model.windowSize()
+    split := model.ShowPreview && width >= splitMinWidth
+    boxWidth, boxHeight := model.listBox()
+    // Borders take 2 columns and rows outside the style's size
+    listStyle := model.Styles.Window.
+        Padding(1, 2).
+        Width(boxWidth - 2).
+        Height(boxHeight - 2)
+    contentWidth := model.listContentWidth()
 
     var listContent strings.Builder
-    asciiArt := `
-  ____   ___   ______
- |    | /   \ |      |
- |__  ||     ||      |
- __|  ||  O  ||_|  |_|
-/  |  ||     |  |  |
-\  `+ "`"+ `  ||     |  |  |
- \____| \___/   |__|
-`
-    listContent.WriteString(primaryStyle.Bold(true).Render(asciiArt) + "\n")
-
-    searchBarStyle := lipgloss.NewStyle().
-        Foreground(lipgloss.Color(currentTheme.PrimaryFg))
+    listContent.WriteString(model.topView(contentWidth) + "\n\n")
 
-    if model.State == StateSearch {
-        searchBarStyle = searchBarStyle.Bold(true)
+    rows := model.visibleRows()
+    pinned := model.pinnedCount()
+    for i := model.Offset; i < min(model.Offset+rows, model.rowCount()); i++ {
+        if model.TreeMode {
+            listContent.WriteString(model.treeRowView(i, contentWidth) + "\n")
+        } else {
+            listContent.WriteString(model.noteRowView(i, contentWidth) + "\n")
+        }
+        if i == pinned-1 && model.hasPinSeparator() {
+            listContent.WriteString(model.Styles.Muted.Render(pinSeparator(contentWidth)) + "\n")
+        }
     }
+    listContent.WriteString("\n" + model.footerView(contentWidth))
+    mainView := listStyle.Render(listContent.String())
+    if model.ShowPreview {
+        if split {
+            mainView = lipgloss.JoinHorizontal(lipgloss.Top, mainView, model.previewView())
+        } else {
+            mainView = lipgloss.JoinVertical(lipgloss.Left, mainView, model.previewView())
+        }
+    }
+    // Never draw taller than the terminal, or it scrolls the top away
+    mainView = lipgloss.NewStyle().MaxHeight(height).Render(mainView)
 
-    listContent.WriteString("\n")
-    listContent.WriteString(searchBarStyle.Render("Search: ") + model.SearchInputText.View())
-    // listContent.WriteString("\n\n")
-
-    // Show filtered results count if searching
-    if model.SearchInputText.Value() != "" {
-        countStyle := mutedStyle
-        listContent.WriteString(countStyle.Render(fmt.Sprintf("(%d/%d)", len(model.DisplayedNotes), len(model.Notes))))
+    if model.ShowHelp {
+        return model.popup(model.helpOverlay())
     }
-    listContent.WriteString("\n\n")
 
+    if model.State == StateEditor {
+        return model.editorView()
+    }
 
-    for i, note := range model.DisplayedNotes {
-        if model.Cursor == i {
-            listContent.WriteString(selectedStyle.Render("▶ "+
-                note.Title) + "\n")
-        } else {
-            listContent.WriteString(mutedStyle.Render("  "+
-                note.Title) + "\n")
+    if model.State == StateNewNote || model.State == StateEdit {
+        title := "New Note"
+        if model.State == StateEdit {
+            title = "Edit Note"
+        } else if model.Form.CopyFrom != "" {
+            title = "Copy Note"
+        }
+        save := model.Keys.Save
+        if model.State == StateNewNote {
+            save = model.Keys.Submit
+            save.SetHelp(save.Help().Key, "save")
         }
+        popupContent := model.Form.view(model.Styles, title, model.popupWidth(64), model.Help.ShortHelpView([]key.Binding{
+            model.Keys.NextField, model.Keys.PrevField, completeKey, save, model.Keys.Cancel,
+        }))
+
+        return model.popup(popupContent)
+    }
+
+    if model.State == StatePalette {
+        return model.popup(model.paletteView())
     }
-    helpText := "i: search, j/k: navigate, Enter: open, n: new, d: delete, q: quit"
-    if model.State == StateSearch {
-          helpText = "Type to search, Esc: exit search mode"
-      }
-    listContent.WriteString("\n" + mutedStyle.Render(helpText))
-    mainView := listStyle.Render(listContent.String())
 
-    if model.State == StateNewNote {
-        popupContent := popupStyle.
+    if model.State == StateAppend {
+        return model.popup(model.appendView())
+    }
+
+    if model.State == StateBulkInput {
+        inputContent := model.Styles.Popup.
             Padding(1, 2).
-            Width(50).
+            Width(model.popupWidth(50)).
             Align(lipgloss.Center).
             Render(
-            "New Note\n\n" +
-            model.NewNoteInputText.View() + "\n\n" +
-            "Press Ctrl-l or Enter to save, Ctrl-c or Esc to cancel",
+            model.Pending.prompt() + "\n\n" +
+            model.BulkInputText.View() + "\n\n" +
+            model.Help.ShortHelpView([]key.Binding{model.Keys.Submit, model.Keys.Cancel}),
             )
 
-        return lipgloss.Place(
-            lipgloss.Width(mainView),
-            lipgloss.Height(mainView),
-            lipgloss.Center,
-            lipgloss.Center,
-            popupContent,
-        )
+        return model.popup(inputContent)
     }
 
-    if model.State == StateDeleteConfirm {
-        confirmContent := popupStyle.
+    if model.State == StateConfirm {
+        title := "Confirm"
+        confirmStyle := model.Styles.Popup
+        if model.Pending.Kind == bulkDelete {
+            title = "Delete Confirmation"
+            confirmStyle = confirmStyle.BorderForeground(model.Styles.Error.GetForeground())
+        }
+        confirmContent := confirmStyle.
             Padding(1, 2).
-            Width(50).
+            Width(model.popupWidth(50)).
             Align(lipgloss.Center).
-            BorderForeground(lipgloss.Color(currentTheme.ErrorFg)).
             Render(
-            "Delete Confirmation\n\n" +
-            "Delete note '" + model.DeleteNoteTitle + "'?\n\n" +
-            "[Y]es / [N]o",
+            title + "\n\n" +
+            model.Pending.question() + "\n\n" +
+            model.buttonsView() + "\n\n" +
+            model.Help.ShortHelpView([]key.Binding{model.Keys.Confirm, model.Keys.Deny}),
             )
 
-        return lipgloss.Place(
-            lipgloss.Width(mainView),
-            lipgloss.Height(mainView),
-            lipgloss.Center,
-            lipgloss.Center,
-            confirmContent,
-            )
+        return model.popup(confirmContent)
     }
 
     return mainView
@@ -0,0 +1,113 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/textarea"
+    "github.com/charmbracelet/lipgloss"
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+// appendForm is the quick append popup, which adds a timestamped entry to
+// the end of a note without opening an editor
+type appendForm struct {
+    NoteID string
+    Title  string
+    Input  textarea.Model
+    // Todo makes entries checkbox items. It is kept between appends.
+    Todo   bool
+}
+
+// startAppend opens the append popup on the selected note
+func (model Model) startAppend() (tea.Model, tea.Cmd) {
+    note := model.selectedNote()
+    if note == nil {
+        return model, nil
+    }
+    input := textarea.New()
+    input.Placeholder = "Entry"
+    input.Prompt = ""
+    input.ShowLineNumbers = false
+    input.FocusedStyle.CursorLine = lipgloss.NewStyle()
+    input.KeyMap.InsertNewline = model.Keys.Newline
+    // Popup padding takes 4 columns
+    input.SetWidth(model.popupWidth(64) - 4)
+    input.SetHeight(3)
+
+    model.Append = appendForm{NoteID: note.ID, Title: note.Title, Input: input, Todo: model.Append.Todo}
+    model.State = StateAppend
+    return model, model.Append.Input.Focus()
+}
+
+func (model Model) updateAppendMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.Cancel):
+        model.State = StateNormal
+        return model, nil
+    case key.Matches(msg, keys.ToggleTodo):
+        model.Append.Todo = !model.Append.Todo
+        return model, nil
+    case key.Matches(msg, keys.Submit):
+        model.State = StateNormal
+        text := strings.TrimSpace(model.Append.Input.Value())
+        if text == "" {
+            return model, nil
+        }
+        return model.appendEntry(model.Append.NoteID, model.Append.Title, text)
+    }
+
+    var cmd tea.Cmd
+    model.Append.Input, cmd = model.Append.Input.Update(msg)
+    return model, cmd
+}
+
+// appendEntry writes text to the end of a note through the store, so the
+// note counts as modified
+func (model Model) appendEntry(id string, title string, text string) (tea.Model, tea.Cmd) {
+    if _, err := model.Store.Append(id, formatEntry(text, model.Append.Todo, time.Now())); err != nil {
+        model.fail(fmt.Errorf("error appending to '%s': %w", title, err))
+        return model, nil
+    }
+    model.notify("Appended to '%s'", title)
+    return model, model.loadNotes()
+}
+
+// formatEntry makes text a list item stamped with the time, or a checkbox
+// item, indenting its other lines under the first
+func formatEntry(text string, todo bool, at time.Time) string {
+    prefix := "- "
+    if todo {
+        prefix = "- [ ] "
+    }
+    lines := strings.Split(text, "\n")
+    lines[0] = prefix + at.Format("2006-01-02 15:04") + " " + lines[0]
+    for i := 1; i < len(lines); i++ {
+        lines[i] = "  " + lines[i]
+    }
+    return strings.Join(lines, "\n")
+}
+
+func (model Model) appendView() string {
+    checkbox := "[ ] "
+    if model.Append.Todo {
+        checkbox = "[x] "
+    }
+    submit := model.Keys.Submit
+    submit.SetHelp(submit.Help().Key, "append")
+    // Padding takes 4 columns
+    width := model.popupWidth(64) - 4
+    model.Help.Width = width
+
+    content := model.Styles.Primary.Bold(true).Render(truncate("Append to '"+model.Append.Title+"'", width)) + "\n\n" +
+        model.Append.Input.View() + "\n\n" +
+        model.Styles.Muted.Render(checkbox+"checkbox item") + "\n\n" +
+        model.Help.ShortHelpView([]key.Binding{submit, model.Keys.Newline, model.Keys.ToggleTodo, model.Keys.Cancel})
+    return model.Styles.Popup.
+        Padding(1, 2).
+        Width(model.popupWidth(64)).
+        Render(content)
+}
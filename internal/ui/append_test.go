@@ -0,0 +1,25 @@
+package ui
+
+import (
+    "testing"
+    "time"
+)
+
+func TestFormatEntry(t *testing.T) {
+    at := time.Date(2024, 5, 6, 7, 8, 0, 0, time.Local)
+    tests := []struct {
+        text string
+        todo bool
+        want string
+    }{
+        {"ship it", false, "- 2024-05-06 07:08 ship it"},
+        {"ship it", true, "- [ ] 2024-05-06 07:08 ship it"},
+        {"first\nsecond", false, "- 2024-05-06 07:08 first\n  second"},
+        {"first\n\nthird", true, "- [ ] 2024-05-06 07:08 first\n  \n  third"},
+    }
+    for _, test := range tests {
+        if got := formatEntry(test.text, test.todo, at); got != test.want {
+            t.Errorf("formatEntry(%q, %v) = %q, want %q", test.text, test.todo, got, test.want)
+        }
+    }
+}
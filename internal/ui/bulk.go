@@ -0,0 +1,412 @@
+package ui
+
+import (
+    "fmt"
+    "slices"
+    "strings"
+
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/charmbracelet/bubbles/key"
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+type bulkKind int
+
+const (
+    bulkDelete bulkKind = iota
+    bulkMove
+    bulkSetTicket
+    bulkAddTag
+    bulkRemoveTag
+    bulkArchive
+    bulkUnarchive
+)
+
+// BulkAction is an action waiting for a value and/or confirmation, applied
+// to the marked notes or the note under the cursor
+type BulkAction struct {
+    Kind  bulkKind
+    Value string
+    Notes []*storage.Note
+}
+
+// needsValue reports whether the action asks for input before confirming
+func (action BulkAction) needsValue() bool {
+    switch action.Kind {
+    case bulkMove, bulkSetTicket, bulkAddTag, bulkRemoveTag:
+        return true
+    }
+    return false
+}
+
+// prompt is the title of the input popup
+func (action BulkAction) prompt() string {
+    switch action.Kind {
+    case bulkMove:
+        return "Move to project:branch"
+    case bulkSetTicket:
+        return "Set ticket"
+    case bulkAddTag:
+        return "Add tag"
+    case bulkRemoveTag:
+        return "Remove tag"
+    }
+    return ""
+}
+
+// placeholder explains the expected input
+func (action BulkAction) placeholder() string {
+    switch action.Kind {
+    case bulkMove:
+        return "project[/subproject][:branch], * for project-wide"
+    case bulkSetTicket:
+        return "Ticket, empty to clear"
+    }
+    return "Tag"
+}
+
+// countNotes names the note when there is one, or counts them
+func countNotes(notes []*storage.Note) string {
+    if len(notes) == 1 {
+        return "note '" + notes[0].Title + "'"
+    }
+    return fmt.Sprintf("%d notes", len(notes))
+}
+
+// question is the confirmation text
+func (action BulkAction) question() string {
+    target := countNotes(action.Notes)
+
+    switch action.Kind {
+    case bulkMove:
+        return fmt.Sprintf("Move %s to %s?", target, action.Value)
+    case bulkSetTicket:
+        if action.Value == "" {
+            return fmt.Sprintf("Clear the ticket of %s?", target)
+        }
+        return fmt.Sprintf("Set ticket of %s to %s?", target, action.Value)
+    case bulkAddTag:
+        return fmt.Sprintf("Tag %s with '%s'?", target, action.Value)
+    case bulkRemoveTag:
+        return fmt.Sprintf("Remove tag '%s' from %s?", action.Value, target)
+    case bulkArchive:
+        return fmt.Sprintf("Archive %s?", target)
+    case bulkUnarchive:
+        return fmt.Sprintf("Unarchive %s?", target)
+    }
+    return fmt.Sprintf("Delete %s?", target)
+}
+
+// done is the toast once the action has been applied
+func (action BulkAction) done() string {
+    target := countNotes(action.Notes)
+
+    switch action.Kind {
+    case bulkMove:
+        return fmt.Sprintf("Moved %s to %s", target, action.Value)
+    case bulkSetTicket:
+        if action.Value == "" {
+            return fmt.Sprintf("Cleared the ticket of %s", target)
+        }
+        return fmt.Sprintf("Set ticket of %s to %s", target, action.Value)
+    case bulkAddTag:
+        return fmt.Sprintf("Tagged %s with '%s'", target, action.Value)
+    case bulkRemoveTag:
+        return fmt.Sprintf("Removed tag '%s' from %s", action.Value, target)
+    case bulkArchive:
+        return fmt.Sprintf("Archived %s", target)
+    case bulkUnarchive:
+        return fmt.Sprintf("Unarchived %s", target)
+    }
+    return fmt.Sprintf("Deleted %s", target)
+}
+
+// apply runs the action against every note, stopping at the first error
+func (action BulkAction) apply(store storage.NoteStore) error {
+    for _, note := range action.Notes {
+        var err error
+        switch action.Kind {
+        case bulkDelete:
+            err = store.Delete(note.ID)
+        case bulkMove:
+            _, err = store.Update(note.ID, moveOptions(note, action.Value)...)
+        case bulkSetTicket:
+            _, err = store.Update(note.ID, storage.WithTicket(action.Value))
+        case bulkAddTag:
+            if !slices.Contains(note.Tags, action.Value) {
+                tags := append(slices.Clone(note.Tags), action.Value)
+                _, err = store.Update(note.ID, storage.WithTags(tags))
+            }
+        case bulkRemoveTag:
+            if slices.Contains(note.Tags, action.Value) {
+                tags := slices.DeleteFunc(slices.Clone(note.Tags), func(tag string) bool {
+                    return tag == action.Value
+                })
+                _, err = store.Update(note.ID, storage.WithTags(tags))
+            }
+        case bulkArchive, bulkUnarchive:
+            _, err = store.Update(note.ID, storage.WithArchived(action.Kind == bulkArchive))
+        }
+        if err != nil {
+            return fmt.Errorf("'%s': %w", note.Title, err)
+        }
+    }
+    return nil
+}
+
+// moveOptions parses "project[/subproject][:branch]". Either side may be
+// left empty to keep the note's current value.
+func moveOptions(note *storage.Note, target string) []storage.UpdateOption {
+    projectPart, branch, hasBranch := strings.Cut(target, ":")
+    var opts []storage.UpdateOption
+    if projectPart != "" {
+        project, subProject, _ := strings.Cut(projectPart, "/")
+        opts = append(opts, storage.WithProject(project), storage.WithSubProject(subProject))
+    }
+    if hasBranch && branch != "" {
+        opts = append(opts, storage.WithBranch(branch))
+    }
+    return opts
+}
+
+// isSelected reports whether a note is marked, or inside the visual range
+func (model Model) isSelected(row int, note *storage.Note) bool {
+    if note == nil {
+        return false
+    }
+    if model.Selected[note.ID] {
+        return true
+    }
+    if model.VisualAnchor < 0 {
+        return false
+    }
+    low, high := min(model.VisualAnchor, model.Cursor), max(model.VisualAnchor, model.Cursor)
+    return row >= low && row <= high
+}
+
+// selectionMark prefixes titles while notes are being marked
+func selectionMark(selected bool) string {
+    if selected {
+        return "● "
+    }
+    return "○ "
+}
+
+// rowNote returns the note shown on a row, nil for tree groups
+func (model Model) rowNote(row int) *storage.Note {
+    if model.TreeMode {
+        if row >= 0 && row < len(model.TreeRows) {
+            return model.TreeRows[row].Node.Note
+        }
+        return nil
+    }
+    if row >= 0 && row < len(model.DisplayedNotes) {
+        return model.DisplayedNotes[row]
+    }
+    return nil
+}
+
+// hasSelection reports whether any note is marked or a range is active
+func (model Model) hasSelection() bool {
+    return len(model.Selected) > 0 || model.VisualAnchor >= 0
+}
+
+// commitVisual marks every note in the visual range and leaves visual mode
+func (model *Model) commitVisual() {
+    if model.VisualAnchor < 0 {
+        return
+    }
+    low, high := min(model.VisualAnchor, model.Cursor), max(model.VisualAnchor, model.Cursor)
+    for row := low; row <= high; row++ {
+        if note := model.rowNote(row); note != nil {
+            model.Selected[note.ID] = true
+        }
+    }
+    model.VisualAnchor = -1
+}
+
+// setDisplayed shows notes in the list. Marks on notes that are no longer
+// shown are dropped, so actions only ever apply to notes on screen. The
+// visual range ends when the rows change, since they now hold other notes,
+// but survives reloads that display the same notes.
+func (model *Model) setDisplayed(notes []*storage.Note) {
+    if !sameNotes(model.DisplayedNotes, notes) {
+        model.VisualAnchor = -1
+    }
+    model.DisplayedNotes = notes
+    if len(model.Selected) == 0 {
+        return
+    }
+    shown := make(map[string]bool, len(notes))
+    for _, note := range notes {
+        shown[note.ID] = true
+    }
+    for id := range model.Selected {
+        if !shown[id] {
+            delete(model.Selected, id)
+        }
+    }
+}
+
+// sameNotes reports whether a and b list the same notes in the same order
+func sameNotes(a, b []*storage.Note) bool {
+    return slices.EqualFunc(a, b, func(x, y *storage.Note) bool { return x.ID == y.ID })
+}
+
+func (model *Model) clearSelection() {
+    model.Selected = make(map[string]bool)
+    model.VisualAnchor = -1
+}
+
+// toggleSelectAll marks every displayed note, or clears the marks when they
+// are all marked already
+func (model *Model) toggleSelectAll() {
+    model.VisualAnchor = -1
+    allSelected := len(model.DisplayedNotes) > 0
+    for _, note := range model.DisplayedNotes {
+        if !model.Selected[note.ID] {
+            allSelected = false
+        }
+    }
+    for _, note := range model.DisplayedNotes {
+        if allSelected {
+            delete(model.Selected, note.ID)
+        } else {
+            model.Selected[note.ID] = true
+        }
+    }
+}
+
+// actionTargets returns the marked notes in list order, or the note under
+// the cursor when nothing is marked
+func (model *Model) actionTargets() []*storage.Note {
+    model.commitVisual()
+    if len(model.Selected) == 0 {
+        if note := model.selectedNote(); note != nil {
+            return []*storage.Note{note}
+        }
+        return nil
+    }
+    var notes []*storage.Note
+    for _, note := range model.DisplayedNotes {
+        if model.Selected[note.ID] {
+            notes = append(notes, note)
+        }
+    }
+    return notes
+}
+
+// startBulkAction collects the targets and asks for a value or confirmation
+func (model Model) startBulkAction(kind bulkKind) (tea.Model, tea.Cmd) {
+    notes := model.actionTargets()
+    if len(notes) == 0 {
+        return model, nil
+    }
+    if kind == bulkArchive {
+        // Archiving already archived notes restores them instead
+        allArchived := true
+        for _, note := range notes {
+            allArchived = allArchived && note.Archived
+        }
+        if allArchived {
+            kind = bulkUnarchive
+        }
+    }
+
+    model.Pending = BulkAction{Kind: kind, Notes: notes}
+    if model.Pending.needsValue() {
+        model.State = StateBulkInput
+        model.BulkInputText.Reset()
+        model.BulkInputText.Placeholder = model.Pending.placeholder()
+        return model, model.BulkInputText.Focus()
+    }
+    model.State = StateConfirm
+    return model, nil
+}
+
+func (model Model) updateBulkInputMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch {
+    case key.Matches(msg, model.Keys.Submit, model.Keys.Save):
+        value := strings.TrimSpace(model.BulkInputText.Value())
+        model.BulkInputText.Blur()
+        if value == "" && model.Pending.Kind != bulkSetTicket {
+            model.State = StateNormal
+            return model, nil
+        }
+        model.Pending.Value = value
+        model.State = StateConfirm
+        return model, nil
+    case key.Matches(msg, model.Keys.Cancel):
+        model.State = StateNormal
+        model.BulkInputText.Blur()
+        return model, nil
+    }
+
+    var cmd tea.Cmd
+    model.BulkInputText, cmd = model.BulkInputText.Update(msg)
+    return model, cmd
+}
+
+// togglePin pins the marked notes, or the selected one, in their project,
+// or unpins them when they are all pinned already
+func (model Model) togglePin() (tea.Model, tea.Cmd) {
+    notes := model.actionTargets()
+    pin := storage.PinNone
+    for _, note := range notes {
+        if note.Pinned == storage.PinNone {
+            pin = storage.PinProject
+        }
+    }
+    return model.pinNotes(notes, pin)
+}
+
+// pinNotes pins or unpins notes straight away, as pinning is easily undone
+func (model Model) pinNotes(notes []*storage.Note, pin storage.Pin) (tea.Model, tea.Cmd) {
+    var err error
+    for _, note := range notes {
+        if err = model.Store.SetPinned(note.ID, pin); err != nil {
+            model.fail(fmt.Errorf("error pinning '%s': %w", note.Title, err))
+            break
+        }
+    }
+    if err == nil && len(notes) > 0 {
+        if pin == storage.PinNone {
+            model.notify("Unpinned %s", countNotes(notes))
+        } else {
+            model.notify("Pinned %s", countNotes(notes))
+        }
+    }
+    model.clearSelection()
+    return model, model.loadNotes()
+}
+
+func (model Model) updateConfirmMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    switch {
+    case key.Matches(msg, model.Keys.Confirm):
+        return model.confirmPending()
+    case key.Matches(msg, model.Keys.Deny):
+        return model.cancelPending()
+    }
+    return model, nil
+}
+
+// confirmPending applies the action waiting for confirmation
+func (model Model) confirmPending() (tea.Model, tea.Cmd) {
+    if err := model.Pending.apply(model.Store); err != nil {
+        model.fail(fmt.Errorf("error updating notes: %w", err))
+    } else {
+        model.notify("%s", model.Pending.done())
+    }
+    model.State = StateNormal
+    model.Pending = BulkAction{}
+    model.clearSelection()
+    return model, model.loadNotes()
+}
+
+// cancelPending drops the action waiting for confirmation
+func (model Model) cancelPending() (tea.Model, tea.Cmd) {
+    model.State = StateNormal
+    model.Pending = BulkAction{}
+    return model, nil
+}
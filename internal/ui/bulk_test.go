@@ -0,0 +1,105 @@
+package ui
+
+import (
+    "fmt"
+    "testing"
+
+    "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/charmbracelet/bubbles/textinput"
+)
+
+// testNotes makes n notes with IDs N0, N1, ... in the project p0
+func testNotes(n int) []*storage.Note {
+    notes := make([]*storage.Note, n)
+    for i := range notes {
+        id := fmt.Sprintf("N%d", i)
+        notes[i] = &storage.Note{ID: id, Title: id, Project: "p0", Branch: "main"}
+    }
+    return notes
+}
+
+func targetIDs(notes []*storage.Note) []string {
+    ids := make([]string, len(notes))
+    for i, note := range notes {
+        ids[i] = note.ID
+    }
+    return ids
+}
+
+func TestActionTargetsOnlyDisplayedNotes(t *testing.T) {
+    notes := testNotes(4)
+    model := Model{Notes: notes, Selected: make(map[string]bool), VisualAnchor: -1}
+    model.setDisplayed(notes)
+    model.Selected["N0"] = true
+    model.Selected["N2"] = true
+
+    // Narrowing the list, as a filter or search does, drops hidden marks
+    model.setDisplayed([]*storage.Note{notes[1], notes[2]})
+    got := targetIDs(model.actionTargets())
+    if fmt.Sprint(got) != "[N2]" {
+        t.Errorf("targets = %v, want [N2]", got)
+    }
+    if model.Selected["N0"] {
+        t.Errorf("hidden note N0 is still marked")
+    }
+}
+
+func TestSetDisplayedEndsVisualRange(t *testing.T) {
+    notes := testNotes(4)
+    model := Model{Notes: notes, Selected: make(map[string]bool), VisualAnchor: -1}
+    model.setDisplayed(notes)
+    model.VisualAnchor = 0
+    model.Cursor = 2
+
+    // A reload showing the same notes keeps the range
+    model.setDisplayed(testNotes(4))
+    if model.VisualAnchor != 0 {
+        t.Errorf("VisualAnchor = %d after a reload, want 0", model.VisualAnchor)
+    }
+
+    model.setDisplayed(notes[2:])
+    if model.VisualAnchor != -1 {
+        t.Errorf("VisualAnchor = %d, want -1", model.VisualAnchor)
+    }
+    model.Cursor = 0
+    got := targetIDs(model.actionTargets())
+    if fmt.Sprint(got) != "[N2]" {
+        t.Errorf("targets = %v, want [N2]", got)
+    }
+}
+
+func TestReloadKeepsVisualRange(t *testing.T) {
+    tagged := func() []*storage.Note {
+        notes := testNotes(4)
+        notes[1].Tags = []string{"t"}
+        notes[2].Tags = []string{"t"}
+        return notes
+    }
+    model := Model{
+        Resolver:        gitctx.Static{Project: "p0", Branch: "main"},
+        Config:          &config.Config{},
+        CurrentFilter:   FilterDisplayAll,
+        SearchInputText: textinput.New(),
+        Selected:        make(map[string]bool),
+        VisualAnchor:    -1,
+    }
+    model.SearchInputText.SetValue("tag:t")
+    model.reloadNotes(tagged())
+    model.VisualAnchor = 0
+    model.Cursor = 1
+
+    model.reloadNotes(tagged())
+    if model.VisualAnchor != 0 {
+        t.Errorf("VisualAnchor = %d after reloading the same notes, want 0", model.VisualAnchor)
+    }
+
+    notes := tagged()
+    notes[2].Tags = nil
+    model.reloadNotes(notes)
+    if model.VisualAnchor != -1 {
+        t.Errorf("VisualAnchor = %d after a note left the search, want -1", model.VisualAnchor)
+    }
+}
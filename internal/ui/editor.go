@@ -0,0 +1,65 @@
+package ui
+
+import (
+    "fmt"
+
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+// editorFinishedMsg is sent when a foreground editor exits, or once a
+// background editor has been started
+type editorFinishedMsg struct {
+    err  error
+    done string // the toast to show on success
+}
+
+// openNote opens a note in the configured editor, at the matching line of a
+// content search result
+func (model Model) openNote(id string) tea.Cmd {
+    return model.launchEditor(id, "Opened")
+}
+
+// openCreatedNote opens a note that was just created
+func (model Model) openCreatedNote(id string) tea.Cmd {
+    return model.launchEditor(id, "Created")
+}
+
+// launchEditor runs the editor on a note, toasting verb and the title once
+// it is done. Foreground editors run through tea.ExecProcess, which hands
+// them the terminal and restores the TUI when they exit.
+func (model Model) launchEditor(id string, verb string) tea.Cmd {
+    var done string
+    for _, note := range model.Notes {
+        if note.ID == id {
+            done = fmt.Sprintf("%s '%s'", verb, note.Title)
+        }
+    }
+    cmd, foreground, err := model.Store.EditCommand(id, model.Matches[id].Line)
+    if err == nil {
+        err = model.Store.RecordOpen(id)
+    }
+    if err != nil {
+        return func() tea.Msg {
+            return editorFinishedMsg{err, done}
+        }
+    }
+    if !foreground {
+        return func() tea.Msg {
+            return editorFinishedMsg{cmd.Start(), done}
+        }
+    }
+    return tea.ExecProcess(cmd, func(err error) tea.Msg {
+        return editorFinishedMsg{err, done}
+    })
+}
+
+// updateEditorFinished reports how opening the note went and reloads the
+// notes, which picks up the edited note's new modified time
+func (model Model) updateEditorFinished(msg editorFinishedMsg) (tea.Model, tea.Cmd) {
+    if msg.err != nil {
+        model.fail(fmt.Errorf("error opening note: %w", msg.err))
+    } else {
+        model.notify("%s", msg.done)
+    }
+    return model, model.loadNotes()
+}
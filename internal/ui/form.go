@@ -0,0 +1,315 @@
+package ui
+
+import (
+    "fmt"
+    "slices"
+    "strings"
+
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/textinput"
+    "github.com/charmbracelet/lipgloss"
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+    fieldTitle = iota
+    fieldProject
+    fieldBranch
+    fieldTicket
+    fieldTags
+    fieldCount
+)
+
+var fieldLabels = [fieldCount]string{"Title", "Project", "Branch", "Ticket", "Tags"}
+
+// noteForm edits the metadata of an existing note, or of a note about to be
+// created when NoteID is empty. CopyFrom is the note a new note copies.
+type noteForm struct {
+    NoteID   string
+    CopyFrom string
+    Inputs []textinput.Model
+    Focus  int
+    Err    string
+    tags   []string
+}
+
+// newNoteForm fills the form from note, offering completions gathered from
+// the existing notes
+func newNoteForm(note storage.Note, existing []*storage.Note) noteForm {
+    form := noteForm{NoteID: note.ID}
+    values := [fieldCount]string{
+        note.Title,
+        note.Scope().Label(),
+        note.Branch,
+        note.Ticket,
+        strings.Join(note.Tags, ", "),
+    }
+    placeholders := [fieldCount]string{
+        "Note title",
+        "project[/subproject]",
+        "Branch, * for project-wide",
+        "Ticket",
+        "Comma separated tags",
+    }
+
+    var projects, branches, tickets []string
+    for _, other := range existing {
+        projects = appendUnique(projects, other.Scope().Label())
+        branches = appendUnique(branches, other.Branch)
+        tickets = appendUnique(tickets, other.Ticket)
+        for _, tag := range other.Tags {
+            form.tags = appendUnique(form.tags, tag)
+        }
+    }
+    suggestions := [fieldCount][]string{nil, projects, branches, tickets, nil}
+
+    for i := range fieldCount {
+        input := textinput.New()
+        input.Prompt = ""
+        input.CharLimit = 100
+        input.Width = 40
+        input.Placeholder = placeholders[i]
+        input.SetValue(values[i])
+        input.ShowSuggestions = true
+        input.SetSuggestions(suggestions[i])
+        form.Inputs = append(form.Inputs, input)
+    }
+    form.refreshTagSuggestions()
+    return form
+}
+
+func appendUnique(values []string, value string) []string {
+    if value == "" || slices.Contains(values, value) {
+        return values
+    }
+    return append(values, value)
+}
+
+// focus moves the cursor to field i
+func (form *noteForm) focus(i int) tea.Cmd {
+    form.Inputs[form.Focus].Blur()
+    form.Focus = (i + fieldCount) % fieldCount
+    return form.Inputs[form.Focus].Focus()
+}
+
+// refreshTagSuggestions completes the last tag of the comma separated list
+func (form *noteForm) refreshTagSuggestions() {
+    value := form.Inputs[fieldTags].Value()
+    prefix := ""
+    if i := strings.LastIndex(value, ","); i >= 0 {
+        prefix = value[:i+1] + " "
+    }
+    present := form.tagList()
+
+    var suggestions []string
+    for _, tag := range form.tags {
+        if !slices.Contains(present, tag) {
+            suggestions = append(suggestions, prefix+tag)
+        }
+    }
+    form.Inputs[fieldTags].SetSuggestions(suggestions)
+}
+
+func (form noteForm) tagList() []string {
+    var tags []string
+    for _, tag := range strings.Split(form.Inputs[fieldTags].Value(), ",") {
+        tags = appendUnique(tags, strings.TrimSpace(tag))
+    }
+    return tags
+}
+
+// values validates the form and returns the note it describes
+func (form noteForm) values() (storage.Note, error) {
+    title := strings.TrimSpace(form.Inputs[fieldTitle].Value())
+    if title == "" {
+        return storage.Note{}, fmt.Errorf("a title is required")
+    }
+    project, subProject, _ := strings.Cut(strings.TrimSpace(form.Inputs[fieldProject].Value()), "/")
+    if project == "" {
+        return storage.Note{}, fmt.Errorf("a project is required")
+    }
+    branch := strings.TrimSpace(form.Inputs[fieldBranch].Value())
+    if branch == "" {
+        branch = "*"
+    }
+
+    return storage.Note{
+        ID:         form.NoteID,
+        Title:      title,
+        Project:    project,
+        SubProject: subProject,
+        Branch:     branch,
+        Ticket:     strings.TrimSpace(form.Inputs[fieldTicket].Value()),
+        Tags:       form.tagList(),
+    }, nil
+}
+
+func (form noteForm) update(msg tea.KeyMsg) (noteForm, tea.Cmd) {
+    var cmd tea.Cmd
+    form.Inputs[form.Focus], cmd = form.Inputs[form.Focus].Update(msg)
+    if form.Focus == fieldTags {
+        form.refreshTagSuggestions()
+    }
+    return form, cmd
+}
+
+func (form noteForm) view(styles Styles, title string, width int, hint string) string {
+    labelStyle := styles.Muted.Width(9)
+    var b strings.Builder
+    b.WriteString(styles.Primary.Bold(true).Render(title) + "\n\n")
+    for i, input := range form.Inputs {
+        label := labelStyle.Render(fieldLabels[i])
+        if i == form.Focus {
+            label = styles.Selected.Width(9).Render(fieldLabels[i])
+        }
+        b.WriteString(label + input.View() + "\n")
+    }
+    if form.Err != "" {
+        b.WriteString("\n" + styles.Error.Render(form.Err) + "\n")
+    }
+    b.WriteString("\n" + hint)
+
+    return styles.Popup.
+        Padding(1, 2).
+        Width(width).
+        Align(lipgloss.Left).
+        Render(b.String())
+}
+
+// startNewNote opens the form for a note in scope
+func (model Model) startNewNote(scope gitctx.Scope, ticket string) (tea.Model, tea.Cmd) {
+    template := storage.Note{
+        Project:    scope.Project,
+        SubProject: scope.SubProject,
+        Branch:     scope.Branch,
+        Ticket:     ticket,
+    }
+    model.Form = newNoteForm(template, model.Notes)
+    model.State = StateNewNote
+    return model, model.Form.focus(fieldTitle)
+}
+
+// startCopy opens the form for a copy of the selected note in scope,
+// prefilled with its title and tags
+func (model Model) startCopy(scope gitctx.Scope) (tea.Model, tea.Cmd) {
+    source := model.selectedNote()
+    if source == nil {
+        return model, nil
+    }
+    template := storage.Note{
+        Title:      source.Title,
+        Project:    scope.Project,
+        SubProject: scope.SubProject,
+        Branch:     scope.Branch,
+        Ticket:     model.Repo.TicketFor(scope),
+        Tags:       source.Tags,
+    }
+    if scope == source.Scope() {
+        template.Title += " (copy)"
+        template.Ticket = source.Ticket
+    }
+    model.Form = newNoteForm(template, model.Notes)
+    model.Form.CopyFrom = source.ID
+    model.State = StateNewNote
+    return model, model.Form.focus(fieldTitle)
+}
+
+// startEdit opens the form on the selected note
+func (model Model) startEdit() (tea.Model, tea.Cmd) {
+    note := model.selectedNote()
+    if note == nil {
+        return model, nil
+    }
+    model.Form = newNoteForm(*note, model.Notes)
+    model.State = StateEdit
+    return model, model.Form.focus(fieldTitle)
+}
+
+func (model Model) updateFormMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.Cancel):
+        model.State = StateNormal
+        return model, nil
+    case key.Matches(msg, keys.Submit):
+        // New notes are mostly just a title, so Enter creates them straight
+        // away; the edit form steps through its fields first
+        if model.State == StateEdit && model.Form.Focus < fieldCount-1 {
+            return model, model.Form.focus(model.Form.Focus + 1)
+        }
+        return model.saveForm()
+    case key.Matches(msg, keys.Save):
+        return model.saveForm()
+    case key.Matches(msg, keys.NextField):
+        return model, model.Form.focus(model.Form.Focus + 1)
+    case key.Matches(msg, keys.PrevField):
+        return model, model.Form.focus(model.Form.Focus - 1)
+    }
+
+    var cmd tea.Cmd
+    model.Form, cmd = model.Form.update(msg)
+    return model, cmd
+}
+
+// saveForm creates and opens the new note, or updates the edited one
+func (model Model) saveForm() (tea.Model, tea.Cmd) {
+    note, err := model.Form.values()
+    if err != nil {
+        model.Form.Err = err.Error()
+        return model, nil
+    }
+
+    if model.State == StateNewNote && model.Form.CopyFrom != "" {
+        copied, err := model.Store.Copy(model.Form.CopyFrom,
+            storage.WithTitle(note.Title),
+            storage.WithProject(note.Project),
+            storage.WithSubProject(note.SubProject),
+            storage.WithBranch(note.Branch),
+            storage.WithTicket(note.Ticket),
+            storage.WithTags(note.Tags),
+        )
+        if err != nil {
+            model.Form.Err = "Error copying note: " + err.Error()
+            return model, nil
+        }
+        where := copied.Scope().Label()
+        if copied.Branch != "*" {
+            where += ":" + copied.Branch
+        }
+        model.State = StateNormal
+        model.notify("Copied to '%s' in %s", copied.Title, where)
+        return model, model.loadNotes()
+    }
+
+    if model.State == StateNewNote {
+        createdNote, err := model.Store.Create(note)
+        if err != nil {
+            model.Form.Err = "Error creating note: " + err.Error()
+            return model, nil
+        }
+        model.Notes = append(model.Notes, createdNote)
+        model.State = StateNormal
+        model.ApplyFilter(model.CurrentFilter)
+        // Open the newly created note
+        return model, model.openCreatedNote(createdNote.ID)
+    }
+
+    _, err = model.Store.Update(note.ID,
+        storage.WithTitle(note.Title),
+        storage.WithProject(note.Project),
+        storage.WithSubProject(note.SubProject),
+        storage.WithBranch(note.Branch),
+        storage.WithTicket(note.Ticket),
+        storage.WithTags(note.Tags),
+    )
+    if err != nil {
+        model.Form.Err = "Error updating note: " + err.Error()
+        return model, nil
+    }
+    model.State = StateNormal
+    model.notify("Updated '%s'", note.Title)
+    return model, model.loadNotes()
+}
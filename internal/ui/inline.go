@@ -0,0 +1,168 @@
+package ui
+
+import (
+    "errors"
+    "fmt"
+    "os"
+    "time"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/textarea"
+    "github.com/charmbracelet/lipgloss"
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+// inlineEditor edits a note's file inside the TUI, for changes too small to
+// be worth starting the external editor
+type inlineEditor struct {
+    NoteID string
+    Title  string
+    Path   string
+    Input  textarea.Model
+    // Saved is the text as last loaded or saved, to tell unsaved changes
+    Saved   string
+    // ModTime is the file's modification time when it was loaded or saved.
+    // A newer one means something else changed the file.
+    ModTime time.Time
+    // Overwrite and Discard are set once the conflict or unsaved changes
+    // have been reported, so pressing the key again goes ahead
+    Overwrite bool
+    Discard   bool
+}
+
+func (editor inlineEditor) dirty() bool {
+    return editor.Input.Value() != editor.Saved
+}
+
+// startInlineEdit loads the selected note's file into the built-in editor
+func (model Model) startInlineEdit() (tea.Model, tea.Cmd) {
+    note := model.selectedNote()
+    if note == nil {
+        return model, nil
+    }
+    content, err := os.ReadFile(note.Path)
+    if err != nil {
+        model.fail(fmt.Errorf("error reading '%s': %w", note.Title, err))
+        return model, nil
+    }
+    info, err := os.Stat(note.Path)
+    if err != nil {
+        model.fail(fmt.Errorf("error reading '%s': %w", note.Title, err))
+        return model, nil
+    }
+
+    input := textarea.New()
+    input.Prompt = ""
+    input.ShowLineNumbers = false
+    input.CharLimit = 0
+    input.MaxHeight = 0
+    input.FocusedStyle.CursorLine = lipgloss.NewStyle()
+    input.SetValue(string(content))
+    // The text area turns tabs into spaces, drops carriage returns and has a
+    // line limit, so saving a file it can't hold as is would change it
+    if input.Value() != string(content) {
+        model.notify("'%s' can't be edited here without changing it, opening the external editor", note.Title)
+        return model, model.openNote(note.ID)
+    }
+    focus := input.Focus()
+    // Start at the top rather than where SetValue left the cursor
+    input, _ = input.Update(tea.KeyMsg{Type: tea.KeyCtrlHome})
+
+    model.Editor = inlineEditor{
+        NoteID:  note.ID,
+        Title:   note.Title,
+        Path:    note.Path,
+        Input:   input,
+        Saved:   string(content),
+        ModTime: info.ModTime(),
+    }
+    model.State = StateEditor
+    model.layoutEditor()
+    if err := model.Store.RecordOpen(note.ID); err != nil {
+        model.fail(fmt.Errorf("error recording open: %w", err))
+    }
+    return model, focus
+}
+
+// layoutEditor sizes the built-in editor to the window, less its borders,
+// padding, title and help lines
+func (model *Model) layoutEditor() {
+    width, height := model.windowSize()
+    model.Editor.Input.SetWidth(max(width-4, 10))
+    model.Editor.Input.SetHeight(max(height-4, 3))
+}
+
+func (model Model) updateEditorMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.EditorSave):
+        return model.saveInlineEdit()
+    case key.Matches(msg, keys.EditorClose):
+        if model.Editor.dirty() && !model.Editor.Discard {
+            model.Editor.Discard = true
+            model.fail(fmt.Errorf("unsaved changes: %s again to discard them", keyHelp(keys.EditorClose.Keys()[:1])))
+            return model, nil
+        }
+        model.State = StateNormal
+        return model, model.loadNotes()
+    case key.Matches(msg, keys.EditorExternal):
+        if model.Editor.dirty() {
+            model.fail(errors.New("save or discard the changes before opening the external editor"))
+            return model, nil
+        }
+        model.State = StateNormal
+        return model, model.openNote(model.Editor.NoteID)
+    }
+
+    model.Editor.Discard = false
+    model.Editor.Overwrite = false
+    var cmd tea.Cmd
+    model.Editor.Input, cmd = model.Editor.Input.Update(msg)
+    return model, cmd
+}
+
+// saveInlineEdit writes the text through the store. A file changed on disk
+// since it was loaded is only overwritten when saving again.
+func (model Model) saveInlineEdit() (tea.Model, tea.Cmd) {
+    editor := &model.Editor
+    if info, err := os.Stat(editor.Path); err == nil && !info.ModTime().Equal(editor.ModTime) && !editor.Overwrite {
+        editor.Overwrite = true
+        model.fail(fmt.Errorf("'%s' changed on disk since it was loaded: %s again to overwrite it",
+            editor.Title, keyHelp(model.Keys.EditorSave.Keys()[:1])))
+        return model, nil
+    }
+
+    value := editor.Input.Value()
+    if _, err := model.Store.Write(editor.NoteID, value); err != nil {
+        model.fail(fmt.Errorf("error saving '%s': %w", editor.Title, err))
+        return model, nil
+    }
+    if info, err := os.Stat(editor.Path); err == nil {
+        editor.ModTime = info.ModTime()
+    }
+    editor.Saved = value
+    editor.Overwrite = false
+    editor.Discard = false
+    model.notify("Saved '%s'", editor.Title)
+    return model, nil
+}
+
+// editorView is the built-in editor, filling the window, with a dot after
+// the title while there are unsaved changes
+func (model Model) editorView() string {
+    width, height := model.windowSize()
+    title := model.Styles.Primary.Bold(true).Render(truncate(model.Editor.Title, width-8))
+    if model.Editor.dirty() {
+        title += model.Styles.Primary.Render(" ●")
+    }
+    model.Help.Width = width - 4
+    help := model.Help.ShortHelpView([]key.Binding{
+        model.Keys.EditorSave, model.Keys.EditorClose, model.Keys.EditorExternal,
+    })
+    // Borders take 2 columns and rows outside the style's size
+    return model.Styles.Window.
+        Padding(0, 1).
+        Width(width - 2).
+        Height(height - 2).
+        Render(title + "\n" + model.Editor.Input.View() + "\n" + help)
+}
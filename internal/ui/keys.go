@@ -0,0 +1,458 @@
+package ui
+
+import (
+    "fmt"
+    "slices"
+    "sort"
+    "strings"
+
+    "github.com/charmbracelet/bubbles/help"
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/lipgloss"
+)
+
+// KeyMap holds the bindings of every TUI mode. Defaults come from
+// keyActions, replaced by a preset and then by the "keys" config entry.
+type KeyMap struct {
+    // List, shared by the flat list and the tree
+    Up                   key.Binding
+    Down                 key.Binding
+    Top                  key.Binding
+    Bottom               key.Binding
+    PageUp               key.Binding
+    PageDown             key.Binding
+    Open                 key.Binding
+    New                  key.Binding
+    Edit                 key.Binding
+    Copy                 key.Binding
+    Append               key.Binding
+    EditInline           key.Binding
+    Search               key.Binding
+    Delete               key.Binding
+    Move                 key.Binding
+    SetTicket            key.Binding
+    AddTag               key.Binding
+    RemoveTag            key.Binding
+    Archive              key.Binding
+    Pin                  key.Binding
+    Mark                 key.Binding
+    MarkRange            key.Binding
+    MarkAll              key.Binding
+    ShowArchived         key.Binding
+    Tree                 key.Binding
+    Sort                 key.Binding
+    Preview              key.Binding
+    PreviewDown          key.Binding
+    PreviewUp            key.Binding
+    FilterBranch         key.Binding
+    FilterProject        key.Binding
+    FilterAll            key.Binding
+    Help                 key.Binding
+    Palette              key.Binding
+    Back                 key.Binding
+    Quit                 key.Binding
+
+    // Tree only
+    Expand               key.Binding
+    Collapse             key.Binding
+    Parent               key.Binding
+    OpenScope            key.Binding
+
+    // Search
+    SearchUp             key.Binding
+    SearchDown           key.Binding
+    SearchPageUp         key.Binding
+    SearchPageDown       key.Binding
+    SearchOpen           key.Binding
+    SearchPreview        key.Binding
+    SearchPreviewDown    key.Binding
+    SearchPreviewUp      key.Binding
+    SearchFilterBranch   key.Binding
+    SearchFilterProject  key.Binding
+    SearchFilterAll      key.Binding
+    SearchSort           key.Binding
+    SearchContent        key.Binding
+    SearchExit           key.Binding
+    SearchQuit           key.Binding
+    SearchPalette        key.Binding
+
+    // Popups with text inputs
+    Submit               key.Binding
+    Save                 key.Binding
+    Cancel               key.Binding
+    NextField            key.Binding
+    PrevField            key.Binding
+    Newline              key.Binding
+    ToggleTodo           key.Binding
+
+    // Command palette
+    PaletteUp            key.Binding
+    PaletteDown          key.Binding
+    PaletteComplete      key.Binding
+
+    // Built-in editor
+    EditorSave           key.Binding
+    EditorClose          key.Binding
+    EditorExternal       key.Binding
+
+    // Confirmation
+    Confirm              key.Binding
+    Deny                 key.Binding
+}
+
+// keyAction describes one configurable binding. Actions in the same group
+// are active at the same time, so they may not share a key.
+type keyAction struct {
+    name    string
+    group   string
+    keys    []string
+    help    string
+    binding func(*KeyMap) *key.Binding
+}
+
+const (
+    groupList    = "list"
+    groupSearch  = "search"
+    groupPopup   = "popup"
+    groupConfirm = "confirm"
+    groupPalette = "palette"
+    groupEditor  = "editor"
+)
+
+var keyActions = []keyAction{
+    {"up", groupList, []string{"k", "up"}, "up", func(k *KeyMap) *key.Binding { return &k.Up }},
+    {"down", groupList, []string{"j", "down"}, "down", func(k *KeyMap) *key.Binding { return &k.Down }},
+    {"top", groupList, []string{"g", "home"}, "top", func(k *KeyMap) *key.Binding { return &k.Top }},
+    {"bottom", groupList, []string{"G", "end"}, "bottom", func(k *KeyMap) *key.Binding { return &k.Bottom }},
+    {"page_up", groupList, []string{"pgup"}, "page up", func(k *KeyMap) *key.Binding { return &k.PageUp }},
+    {"page_down", groupList, []string{"pgdown"}, "page down", func(k *KeyMap) *key.Binding { return &k.PageDown }},
+    {"open", groupList, []string{"enter", "ctrl+l"}, "open", func(k *KeyMap) *key.Binding { return &k.Open }},
+    {"new", groupList, []string{"n"}, "new", func(k *KeyMap) *key.Binding { return &k.New }},
+    {"edit", groupList, []string{"e"}, "edit", func(k *KeyMap) *key.Binding { return &k.Edit }},
+    {"copy", groupList, []string{"c"}, "copy", func(k *KeyMap) *key.Binding { return &k.Copy }},
+    {"append", groupList, []string{">"}, "append", func(k *KeyMap) *key.Binding { return &k.Append }},
+    {"edit_inline", groupList, []string{"E"}, "edit here", func(k *KeyMap) *key.Binding { return &k.EditInline }},
+    {"search", groupList, []string{"i"}, "search", func(k *KeyMap) *key.Binding { return &k.Search }},
+    {"delete", groupList, []string{"d"}, "delete", func(k *KeyMap) *key.Binding { return &k.Delete }},
+    {"move", groupList, []string{"m"}, "move", func(k *KeyMap) *key.Binding { return &k.Move }},
+    {"set_ticket", groupList, []string{"T"}, "ticket", func(k *KeyMap) *key.Binding { return &k.SetTicket }},
+    {"add_tag", groupList, []string{"+"}, "add tag", func(k *KeyMap) *key.Binding { return &k.AddTag }},
+    {"remove_tag", groupList, []string{"-"}, "remove tag", func(k *KeyMap) *key.Binding { return &k.RemoveTag }},
+    {"archive", groupList, []string{"x"}, "archive", func(k *KeyMap) *key.Binding { return &k.Archive }},
+    {"pin", groupList, []string{"!"}, "pin/unpin", func(k *KeyMap) *key.Binding { return &k.Pin }},
+    {"mark", groupList, []string{" "}, "mark", func(k *KeyMap) *key.Binding { return &k.Mark }},
+    {"mark_range", groupList, []string{"V"}, "mark range", func(k *KeyMap) *key.Binding { return &k.MarkRange }},
+    {"mark_all", groupList, []string{"A"}, "mark all", func(k *KeyMap) *key.Binding { return &k.MarkAll }},
+    {"show_archived", groupList, []string{"Z"}, "show archived", func(k *KeyMap) *key.Binding { return &k.ShowArchived }},
+    {"tree", groupList, []string{"t"}, "tree", func(k *KeyMap) *key.Binding { return &k.Tree }},
+    {"sort", groupList, []string{"s"}, "sort", func(k *KeyMap) *key.Binding { return &k.Sort }},
+    {"preview", groupList, []string{"tab"}, "preview", func(k *KeyMap) *key.Binding { return &k.Preview }},
+    {"preview_down", groupList, []string{"J", "ctrl+d"}, "scroll preview down", func(k *KeyMap) *key.Binding { return &k.PreviewDown }},
+    {"preview_up", groupList, []string{"K", "ctrl+u"}, "scroll preview up", func(k *KeyMap) *key.Binding { return &k.PreviewUp }},
+    {"filter_branch", groupList, []string{"b"}, "branch notes", func(k *KeyMap) *key.Binding { return &k.FilterBranch }},
+    {"filter_project", groupList, []string{"p"}, "project notes", func(k *KeyMap) *key.Binding { return &k.FilterProject }},
+    {"filter_all", groupList, []string{"a"}, "all notes", func(k *KeyMap) *key.Binding { return &k.FilterAll }},
+    {"help", groupList, []string{"?"}, "help", func(k *KeyMap) *key.Binding { return &k.Help }},
+    {"palette", groupList, []string{":", "ctrl+p"}, "commands", func(k *KeyMap) *key.Binding { return &k.Palette }},
+    {"back", groupList, []string{"esc"}, "clear marks/quit", func(k *KeyMap) *key.Binding { return &k.Back }},
+    {"quit", groupList, []string{"q", "ctrl+c"}, "quit", func(k *KeyMap) *key.Binding { return &k.Quit }},
+
+    {"expand", groupList, []string{"l", "right"}, "expand", func(k *KeyMap) *key.Binding { return &k.Expand }},
+    {"collapse", groupList, []string{"h", "left"}, "collapse", func(k *KeyMap) *key.Binding { return &k.Collapse }},
+    {"parent", groupList, []string{"P", "backspace"}, "parent", func(k *KeyMap) *key.Binding { return &k.Parent }},
+    {"open_scope", groupList, []string{"o"}, "open scope note", func(k *KeyMap) *key.Binding { return &k.OpenScope }},
+
+    {"search_up", groupSearch, []string{"ctrl+k", "up"}, "up", func(k *KeyMap) *key.Binding { return &k.SearchUp }},
+    {"search_down", groupSearch, []string{"ctrl+j", "down"}, "down", func(k *KeyMap) *key.Binding { return &k.SearchDown }},
+    {"search_page_up", groupSearch, []string{"pgup"}, "page up", func(k *KeyMap) *key.Binding { return &k.SearchPageUp }},
+    {"search_page_down", groupSearch, []string{"pgdown"}, "page down", func(k *KeyMap) *key.Binding { return &k.SearchPageDown }},
+    {"search_open", groupSearch, []string{"ctrl+l"}, "open", func(k *KeyMap) *key.Binding { return &k.SearchOpen }},
+    {"search_preview", groupSearch, []string{"tab"}, "preview", func(k *KeyMap) *key.Binding { return &k.SearchPreview }},
+    {"search_preview_down", groupSearch, []string{"ctrl+d"}, "scroll preview down", func(k *KeyMap) *key.Binding { return &k.SearchPreviewDown }},
+    {"search_preview_up", groupSearch, []string{"ctrl+u"}, "scroll preview up", func(k *KeyMap) *key.Binding { return &k.SearchPreviewUp }},
+    {"search_filter_branch", groupSearch, []string{"ctrl+b"}, "branch notes", func(k *KeyMap) *key.Binding { return &k.SearchFilterBranch }},
+    {"search_filter_project", groupSearch, []string{"ctrl+p"}, "project notes", func(k *KeyMap) *key.Binding { return &k.SearchFilterProject }},
+    {"search_filter_all", groupSearch, []string{"ctrl+a"}, "all notes", func(k *KeyMap) *key.Binding { return &k.SearchFilterAll }},
+    {"search_sort", groupSearch, []string{"ctrl+s"}, "sort", func(k *KeyMap) *key.Binding { return &k.SearchSort }},
+    {"search_content", groupSearch, []string{"ctrl+t"}, "titles/content", func(k *KeyMap) *key.Binding { return &k.SearchContent }},
+    {"search_exit", groupSearch, []string{"esc"}, "exit search", func(k *KeyMap) *key.Binding { return &k.SearchExit }},
+    {"search_quit", groupSearch, []string{"ctrl+c"}, "quit", func(k *KeyMap) *key.Binding { return &k.SearchQuit }},
+    // Ctrl-p, which most terminals send for Ctrl-Shift-p, filters by project
+    // while searching
+    {"search_palette", groupSearch, []string{"alt+p", "f1"}, "commands", func(k *KeyMap) *key.Binding { return &k.SearchPalette }},
+
+    {"submit", groupPopup, []string{"enter"}, "next/save", func(k *KeyMap) *key.Binding { return &k.Submit }},
+    {"save", groupPopup, []string{"ctrl+l"}, "save", func(k *KeyMap) *key.Binding { return &k.Save }},
+    {"cancel", groupPopup, []string{"esc", "ctrl+c"}, "cancel", func(k *KeyMap) *key.Binding { return &k.Cancel }},
+    {"next_field", groupPopup, []string{"ctrl+j"}, "next field", func(k *KeyMap) *key.Binding { return &k.NextField }},
+    {"prev_field", groupPopup, []string{"ctrl+k", "shift+tab"}, "prev field", func(k *KeyMap) *key.Binding { return &k.PrevField }},
+    {"newline", groupPopup, []string{"alt+enter"}, "new line", func(k *KeyMap) *key.Binding { return &k.Newline }},
+    {"toggle_todo", groupPopup, []string{"ctrl+t"}, "checkbox", func(k *KeyMap) *key.Binding { return &k.ToggleTodo }},
+
+    {"palette_up", groupPalette, []string{"up", "ctrl+k"}, "up", func(k *KeyMap) *key.Binding { return &k.PaletteUp }},
+    {"palette_down", groupPalette, []string{"down", "ctrl+j"}, "down", func(k *KeyMap) *key.Binding { return &k.PaletteDown }},
+    {"palette_complete", groupPalette, []string{"tab"}, "complete", func(k *KeyMap) *key.Binding { return &k.PaletteComplete }},
+
+    {"editor_save", groupEditor, []string{"ctrl+s"}, "save", func(k *KeyMap) *key.Binding { return &k.EditorSave }},
+    {"editor_close", groupEditor, []string{"esc"}, "close", func(k *KeyMap) *key.Binding { return &k.EditorClose }},
+    {"editor_external", groupEditor, []string{"ctrl+o"}, "external editor", func(k *KeyMap) *key.Binding { return &k.EditorExternal }},
+
+    {"confirm", groupConfirm, []string{"y", "Y"}, "yes", func(k *KeyMap) *key.Binding { return &k.Confirm }},
+    {"deny", groupConfirm, []string{"n", "N", "esc"}, "no", func(k *KeyMap) *key.Binding { return &k.Deny }},
+}
+
+// sharedActions lists actions of other groups that a group's mode also
+// handles, so their keys are checked for conflicts in that group too
+var sharedActions = map[string][]string{
+    groupPalette: {"submit", "cancel"},
+}
+
+// keyPresets replace the default keys of some actions
+var keyPresets = map[string]map[string][]string{
+    "default": {},
+    // vim moves the search and popup keys off Ctrl-j/k/l
+    "vim": {
+        "open":                  {"enter"},
+        "search":                {"i", "/"},
+        "page_up":               {"pgup", "ctrl+b"},
+        "page_down":             {"pgdown", "ctrl+f"},
+        "search_up":             {"ctrl+p", "up"},
+        "search_down":           {"ctrl+n", "down"},
+        "search_open":           {"enter"},
+        "search_filter_project": {"ctrl+o"},
+        "save":                  {"ctrl+s"},
+        "next_field":            {"ctrl+n"},
+        "prev_field":            {"ctrl+p", "shift+tab"},
+        "palette_up":            {"ctrl+p", "up"},
+        "palette_down":          {"ctrl+n", "down"},
+    },
+    "emacs": {
+        "up":                    {"ctrl+p", "up"},
+        "down":                  {"ctrl+n", "down"},
+        "top":                   {"alt+<", "home"},
+        "bottom":                {"alt+>", "end"},
+        "page_up":               {"alt+v", "pgup"},
+        "page_down":             {"ctrl+v", "pgdown"},
+        "open":                  {"enter"},
+        "back":                  {"esc", "ctrl+g"},
+        "search":                {"i", "ctrl+s"},
+        "search_up":             {"ctrl+p", "up"},
+        "search_down":           {"ctrl+n", "down"},
+        "search_page_up":        {"alt+v", "pgup"},
+        "search_page_down":      {"ctrl+v", "pgdown"},
+        "search_open":           {"enter"},
+        "search_preview_down":   {"alt+n"},
+        "search_preview_up":     {"alt+p"},
+        "search_filter_branch":  {"alt+b"},
+        "search_filter_project": {"alt+o"},
+        "search_filter_all":     {"alt+a"},
+        "search_sort":           {"alt+s"},
+        "search_exit":           {"esc", "ctrl+g"},
+        "search_palette":        {"alt+x", "f1"},
+        "save":                  {"ctrl+s"},
+        "cancel":                {"esc", "ctrl+g", "ctrl+c"},
+        "next_field":            {"ctrl+n"},
+        "prev_field":            {"ctrl+p", "shift+tab"},
+        "palette":               {":", "alt+x"},
+        "editor_close":          {"esc", "ctrl+g"},
+        "palette_up":            {"ctrl+p", "up"},
+        "palette_down":          {"ctrl+n", "down"},
+    },
+}
+
+// KeyPresets lists the preset names accepted by the key_preset setting
+func KeyPresets() []string {
+    var names []string
+    for name := range keyPresets {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// NewKeyMap builds the keymap from a preset ("" for the default) and
+// per-action overrides. Unknown presets or actions, and two actions of the
+// same mode sharing a key, are errors.
+func NewKeyMap(preset string, overrides map[string][]string) (KeyMap, error) {
+    if preset == "" {
+        preset = "default"
+    }
+    presetKeys, ok := keyPresets[preset]
+    if !ok {
+        return KeyMap{}, fmt.Errorf("unknown key preset %q (available: %s)",
+            preset, strings.Join(KeyPresets(), ", "))
+    }
+
+    known := make(map[string]bool)
+    for _, action := range keyActions {
+        known[action.name] = true
+    }
+    for name := range overrides {
+        if !known[name] {
+            return KeyMap{}, fmt.Errorf("unknown key action %q", name)
+        }
+    }
+
+    var keys KeyMap
+    // owners maps group and key to the action using it
+    owners := make(map[string]string)
+    var conflicts []string
+    for _, action := range keyActions {
+        bound := action.keys
+        if presetBound, ok := presetKeys[action.name]; ok {
+            bound = presetBound
+        }
+        if overridden, ok := overrides[action.name]; ok {
+            bound = overridden
+        }
+        bound = normalizeKeys(bound)
+
+        groups := []string{action.group}
+        for group, shared := range sharedActions {
+            if slices.Contains(shared, action.name) {
+                groups = append(groups, group)
+            }
+        }
+        for _, group := range groups {
+            for _, k := range bound {
+                owner := group + "\x00" + k
+                if other, taken := owners[owner]; taken {
+                    conflicts = append(conflicts, fmt.Sprintf("%q is bound to both %s and %s",
+                        displayKey(k), other, action.name))
+                    continue
+                }
+                owners[owner] = action.name
+            }
+        }
+
+        binding := key.NewBinding(key.WithKeys(bound...), key.WithHelp(keyHelp(bound), action.help))
+        if len(bound) == 0 {
+            binding.SetEnabled(false)
+        }
+        *action.binding(&keys) = binding
+    }
+
+    if len(conflicts) > 0 {
+        return keys, fmt.Errorf("conflicting keybindings: %s", strings.Join(conflicts, "; "))
+    }
+    return keys, nil
+}
+
+// DefaultKeyMap is the keymap without a preset or overrides
+func DefaultKeyMap() KeyMap {
+    keys, _ := NewKeyMap("", nil)
+    return keys
+}
+
+// normalizeKeys accepts "space" for the space bar, the name Bubble Tea uses
+// being a literal " "
+func normalizeKeys(keys []string) []string {
+    var normalized []string
+    for _, k := range keys {
+        if k == "space" {
+            k = " "
+        }
+        normalized = append(normalized, k)
+    }
+    return normalized
+}
+
+func displayKey(k string) string {
+    switch k {
+    case " ":
+        return "space"
+    case "up":
+        return "↑"
+    case "down":
+        return "↓"
+    case "left":
+        return "←"
+    case "right":
+        return "→"
+    }
+    return k
+}
+
+func keyHelp(keys []string) string {
+    var shown []string
+    for _, k := range keys {
+        shown = append(shown, displayKey(k))
+    }
+    return strings.Join(shown, "/")
+}
+
+// ShortHelp is the help line of the flat list
+func (keys KeyMap) ShortHelp() []key.Binding {
+    return []key.Binding{keys.Search, keys.Up, keys.Down, keys.Open, keys.New, keys.Edit,
+        keys.Append, keys.Delete, keys.Sort, keys.Tree, keys.Preview, keys.Palette, keys.Help, keys.Quit}
+}
+
+// FullHelp is the help overlay, one column per kind of action
+func (keys KeyMap) FullHelp() [][]key.Binding {
+    return [][]key.Binding{
+        {keys.Up, keys.Down, keys.Top, keys.Bottom, keys.PageUp, keys.PageDown, keys.Open,
+            keys.Search, keys.Palette, keys.Back, keys.Quit},
+        {keys.New, keys.Edit, keys.Copy, keys.EditInline, keys.Append, keys.Delete, keys.Move, keys.SetTicket, keys.AddTag, keys.RemoveTag,
+            keys.Archive, keys.Pin},
+        {keys.Mark, keys.MarkRange, keys.MarkAll, keys.ShowArchived, keys.FilterBranch,
+            keys.FilterProject, keys.FilterAll},
+        {keys.Tree, keys.Expand, keys.Collapse, keys.Parent, keys.OpenScope, keys.Sort,
+            keys.Preview, keys.PreviewDown, keys.PreviewUp},
+    }
+}
+
+// selectionHelp is the help line while notes are marked
+func (keys KeyMap) selectionHelp() []key.Binding {
+    return []key.Binding{keys.Mark, keys.MarkRange, keys.MarkAll, keys.Delete, keys.Move,
+        keys.SetTicket, keys.AddTag, keys.RemoveTag, keys.Archive, keys.Pin, keys.Back}
+}
+
+// treeHelp is the help line of the tree view
+func (keys KeyMap) treeHelp() []key.Binding {
+    return []key.Binding{keys.Expand, keys.Collapse, keys.Parent, keys.OpenScope, keys.New,
+        keys.Tree, keys.Help, keys.Quit}
+}
+
+// searchHelp is the help line while searching
+func (keys KeyMap) searchHelp() []key.Binding {
+    return []key.Binding{keys.SearchUp, keys.SearchDown, keys.SearchOpen, keys.SearchExit,
+        keys.SearchContent, keys.SearchSort, keys.SearchPreview, keys.SearchPreviewDown, keys.SearchPreviewUp, keys.SearchPalette}
+}
+
+// completeKey is the text inputs' own suggestion key, shown in popup help
+var completeKey = key.NewBinding(key.WithKeys("tab"), key.WithHelp("tab", "complete"))
+
+func newHelp(styles Styles) help.Model {
+    model := help.New()
+    model.Styles.ShortKey = styles.Primary
+    model.Styles.ShortDesc = styles.Muted
+    model.Styles.ShortSeparator = styles.Muted
+    model.Styles.Ellipsis = styles.Muted
+    model.Styles.FullKey = styles.Primary
+    model.Styles.FullDesc = styles.Muted
+    model.Styles.FullSeparator = styles.Muted
+    return model
+}
+
+// helpOverlay lists every list binding, in as many columns as fit
+func (model Model) helpOverlay() string {
+    width := model.popupWidth(110) - 6
+    var rows []string
+    var row [][]key.Binding
+    for _, column := range model.Keys.FullHelp() {
+        candidate := append(row, column)
+        if len(row) > 0 && lipgloss.Width(model.Help.FullHelpView(candidate)) > width {
+            rows = append(rows, model.Help.FullHelpView(row))
+            candidate = [][]key.Binding{column}
+        }
+        row = candidate
+    }
+    rows = append(rows, model.Help.FullHelpView(row))
+
+    content := model.Styles.Primary.Bold(true).Render("Keys") + "\n\n" +
+        strings.Join(rows, "\n\n") + "\n\n" +
+        model.Styles.Muted.Render("Press any key to close")
+    return model.Styles.Popup.
+        Padding(1, 2).
+        Render(content)
+}
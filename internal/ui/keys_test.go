@@ -0,0 +1,98 @@
+package ui
+
+import (
+    "reflect"
+    "slices"
+    "strings"
+    "testing"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/textarea"
+)
+
+func TestNewKeyMap(t *testing.T) {
+    tests := []struct {
+        name      string
+        preset    string
+        overrides map[string][]string
+        wantErr   string
+    }{
+        {"default", "", nil, ""},
+        {"vim", "vim", nil, ""},
+        {"emacs", "emacs", nil, ""},
+        {"unknown preset", "nano", nil, `unknown key preset "nano"`},
+        {"unknown action", "", map[string][]string{"fly": {"f"}}, `unknown key action "fly"`},
+        {"conflict", "", map[string][]string{"delete": {"j"}}, `"j" is bound to both down and delete`},
+        {"other mode", "", map[string][]string{"search_exit": {"j", "esc"}}, ""},
+        {"palette submit", "", map[string][]string{"palette_down": {"enter"}}, `"enter" is bound to both submit and palette_down`},
+        {"palette cancel", "emacs", map[string][]string{"palette_complete": {"ctrl+g"}}, `"ctrl+g" is bound to both cancel and palette_complete`},
+        {"unbound", "", map[string][]string{"delete": {}}, ""},
+        {"editor", "emacs", map[string][]string{"editor_save": {"ctrl+g"}}, `"ctrl+g" is bound to both editor_save and editor_close`},
+    }
+    for _, test := range tests {
+        _, err := NewKeyMap(test.preset, test.overrides)
+        switch {
+        case test.wantErr == "" && err != nil:
+            t.Errorf("%s: unexpected error %v", test.name, err)
+        case test.wantErr != "" && (err == nil || !strings.Contains(err.Error(), test.wantErr)):
+            t.Errorf("%s: error = %v, want one containing %s", test.name, err, test.wantErr)
+        }
+    }
+}
+
+// The presets exist to keep Ctrl-j and Ctrl-l free for terminal multiplexers
+func TestPresetsAvoidMultiplexerKeys(t *testing.T) {
+    for _, preset := range []string{"vim", "emacs"} {
+        keys, err := NewKeyMap(preset, nil)
+        if err != nil {
+            t.Fatal(err)
+        }
+        for _, action := range keyActions {
+            for _, k := range []string{"ctrl+j", "ctrl+l"} {
+                if slices.Contains(action.binding(&keys).Keys(), k) {
+                    t.Errorf("%s preset binds %s to %s", preset, k, action.name)
+                }
+            }
+        }
+    }
+}
+
+// Every shipped preset binds each key once per mode, and leaves the built-in
+// editor the keys its text area edits with
+func TestPresetsHaveNoConflicts(t *testing.T) {
+    var editing []string
+    textKeys := reflect.ValueOf(textarea.DefaultKeyMap)
+    for i := range textKeys.NumField() {
+        if binding, ok := textKeys.Field(i).Interface().(key.Binding); ok {
+            editing = append(editing, binding.Keys()...)
+        }
+    }
+
+    for _, preset := range KeyPresets() {
+        keys, err := NewKeyMap(preset, nil)
+        if err != nil {
+            t.Errorf("%s preset: %v", preset, err)
+            continue
+        }
+        owners := make(map[string]string)
+        for _, action := range keyActions {
+            groups := []string{action.group}
+            for group, shared := range sharedActions {
+                if slices.Contains(shared, action.name) {
+                    groups = append(groups, group)
+                }
+            }
+            for _, k := range action.binding(&keys).Keys() {
+                for _, group := range groups {
+                    if other, taken := owners[group+" "+k]; taken {
+                        t.Errorf("%s preset binds %s to %s and %s", preset, k, other, action.name)
+                    }
+                    owners[group+" "+k] = action.name
+                }
+                if action.group == groupEditor && slices.Contains(editing, k) {
+                    t.Errorf("%s preset binds the text area's %s to %s", preset, k, action.name)
+                }
+            }
+        }
+    }
+}
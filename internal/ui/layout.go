@@ -0,0 +1,112 @@
+package ui
+
+import (
+    "strings"
+
+    "github.com/charmbracelet/lipgloss"
+    "github.com/charmbracelet/x/ansi"
+)
+
+// Terminals shorter than this drop the banner, as does the compact setting
+const compactMaxHeight = 30
+
+const banner = `
+  ____   ___   ______
+ |    | /   \ |      |
+ |__  ||     ||      |
+ __|  ||  O  ||_|  |_|
+/  |  ||     |  |  |
+\  ` + "`" + `  ||     |  |  |
+ \____| \___/   |__|
+`
+
+// compact reports whether the banner is left out to make room for notes
+func (model Model) compact() bool {
+    _, height := model.windowSize()
+    return model.Config.Compact || height < compactMaxHeight
+}
+
+// listBox returns the outer size of the list window, which shares the
+// terminal with the preview when it is shown
+func (model Model) listBox() (int, int) {
+    width, height := model.windowSize()
+    switch {
+    case !model.ShowPreview:
+        return width, height
+    case width >= splitMinWidth:
+        return width * 2 / 5, height
+    default:
+        // The preview's title and borders take 3 rows
+        return width, max(height-model.Preview.Height-3, 8)
+    }
+}
+
+// listContentWidth is the width inside the list window's borders and padding
+func (model Model) listContentWidth() int {
+    width, _ := model.listBox()
+    return max(width-6, 10)
+}
+
+// visibleRows is how many notes or tree rows fit in the list window below
+// the header and above the help text. Content search results take two lines,
+// and the separator under pinned notes one.
+func (model Model) visibleRows() int {
+    _, height := model.listBox()
+    width := model.listContentWidth()
+    top := lipgloss.Height(model.topView(width))
+    help := lipgloss.Height(model.footerView(width))
+    // Borders and padding take 4 rows, the blank lines around the rows 2
+    lines := height - 4 - top - help - 2
+    if model.hasPinSeparator() {
+        lines--
+    }
+    return max(lines/model.rowHeight(), 1)
+}
+
+// pinSeparator is the line between pinned notes and the rest of the list
+func pinSeparator(width int) string {
+    label := "─ pinned "
+    return label + strings.Repeat("─", max(width-lipgloss.Width(label), 0))
+}
+
+// scrollToCursor moves the list window so the cursor row is visible
+func (model *Model) scrollToCursor() {
+    rows := model.visibleRows()
+    count := model.rowCount()
+    model.Cursor = max(min(model.Cursor, count-1), 0)
+    if model.Cursor >= model.Offset+rows {
+        model.Offset = model.Cursor - rows + 1
+    }
+    if model.Cursor < model.Offset {
+        model.Offset = model.Cursor
+    }
+    // Don't leave empty rows at the bottom when rows are removed
+    model.Offset = max(min(model.Offset, count-rows), 0)
+}
+
+// moveCursor moves the cursor by delta rows, stopping at either end
+func (model *Model) moveCursor(delta int) {
+    model.Cursor = max(min(model.Cursor+delta, model.rowCount()-1), 0)
+}
+
+// truncate shortens s to width columns, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+    return ansi.Truncate(s, max(width, 1), "…")
+}
+
+// popup centers content in the window, replacing the view behind it
+func (model Model) popup(content string) string {
+    width, height := model.windowSize()
+    return lipgloss.Place(width, height, lipgloss.Center, lipgloss.Center, content)
+}
+
+// popupWidth narrows a popup to fit small terminals
+func (model Model) popupWidth(preferred int) int {
+    width, _ := model.windowSize()
+    return max(min(preferred, width-4), 20)
+}
+
+// footerView is the help line at the bottom of the list window
+func (model Model) footerView(width int) string {
+    return model.helpView(width)
+}
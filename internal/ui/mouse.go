@@ -0,0 +1,213 @@
+package ui
+
+import (
+    "reflect"
+    "strings"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+    "github.com/charmbracelet/x/ansi"
+)
+
+// Two clicks on the same row within this long open the note
+const doubleClickTime = 400 * time.Millisecond
+
+// Lines the preview scrolls per wheel step
+const wheelLines = 3
+
+// filterTabs are the clickable filters above the search bar, in the order
+// they are drawn
+var filterTabs = []struct {
+    Label  string
+    Filter FilterFunc
+}{
+    {"All", FilterDisplayAll},
+    {"Project", FilterByProject},
+    {"Branch", FilterByBranch},
+}
+
+// click is the last left click on a list row, for spotting double clicks
+type click struct {
+    Row int
+    At  time.Time
+}
+
+// sameFilter reports whether two filter funcs are the same function
+func sameFilter(a, b FilterFunc) bool {
+    return reflect.ValueOf(a).Pointer() == reflect.ValueOf(b).Pointer()
+}
+
+// tabsView renders the filter tabs, highlighting the current filter
+func (model Model) tabsView() string {
+    tabs := make([]string, len(filterTabs))
+    for i, tab := range filterTabs {
+        style := model.Styles.Muted.Padding(0, 1)
+        if sameFilter(tab.Filter, model.CurrentFilter) {
+            style = model.Styles.Primary.Reverse(true).Padding(0, 1)
+        }
+        tabs[i] = style.Render(tab.Label)
+    }
+    return strings.Join(tabs, " ")
+}
+
+// confirmButtons are the clickable answers in the confirm popup
+var confirmButtons = []string{"Yes", "No"}
+
+// buttonsView renders the confirm popup's buttons, the first one highlighted
+func (model Model) buttonsView() string {
+    buttons := make([]string, len(confirmButtons))
+    for i, label := range confirmButtons {
+        style := model.Styles.Muted.Padding(0, 1)
+        if i == 0 {
+            style = model.Styles.Primary.Reverse(true).Padding(0, 1)
+            if model.Pending.Kind == bulkDelete {
+                style = model.Styles.Error.Reverse(true).Padding(0, 1)
+            }
+        }
+        buttons[i] = style.Render(label)
+    }
+    return strings.Join(buttons, "  ")
+}
+
+// labelAt finds which of labels, drawn padded and joined by sep as by
+// tabsView and buttonsView, is under the cell (x, y) of the rendered view.
+// It returns -1 when the click misses them.
+func labelAt(view string, x, y int, labels []string, sep string) int {
+    lines := strings.Split(view, "\n")
+    if y < 0 || y >= len(lines) {
+        return -1
+    }
+    padded := make([]string, len(labels))
+    for i, label := range labels {
+        padded[i] = " " + label + " "
+    }
+    line := ansi.Strip(lines[y])
+    start := strings.Index(line, strings.Join(padded, sep))
+    if start < 0 {
+        return -1
+    }
+    left := ansi.StringWidth(line[:start])
+    for i, label := range padded {
+        width := ansi.StringWidth(label)
+        if x >= left && x < left+width {
+            return i
+        }
+        left += width + ansi.StringWidth(sep)
+    }
+    return -1
+}
+
+// rowAt returns the list row drawn at (x, y), or false when there is none
+func (model Model) rowAt(x, y int) (int, bool) {
+    boxWidth, _ := model.listBox()
+    if x >= boxWidth || model.inPreview(x, y) {
+        return 0, false
+    }
+    // Rows start below the border, padding, header and blank line
+    line := 2 + lipgloss.Height(model.topView(model.listContentWidth())) + 1
+    pinned := model.pinnedCount()
+    for i := model.Offset; i < min(model.Offset+model.visibleRows(), model.rowCount()); i++ {
+        if y >= line && y < line+model.rowHeight() {
+            return i, true
+        }
+        line += model.rowHeight()
+        if i == pinned-1 && model.hasPinSeparator() {
+            line++
+        }
+    }
+    return 0, false
+}
+
+// inPreview reports whether (x, y) falls on the preview window
+func (model Model) inPreview(x, y int) bool {
+    if !model.ShowPreview {
+        return false
+    }
+    width, _ := model.windowSize()
+    boxWidth, boxHeight := model.listBox()
+    if width >= splitMinWidth {
+        return x >= boxWidth
+    }
+    return y >= boxHeight
+}
+
+// updateMouse handles clicks and the wheel. Popups only take clicks on their
+// buttons; the list takes clicks on rows and filter tabs.
+func (model Model) updateMouse(msg tea.MouseMsg) (tea.Model, tea.Cmd) {
+    press := msg.Action == tea.MouseActionPress
+    if model.ShowHelp {
+        if press {
+            model.ShowHelp = false
+        }
+        return model, nil
+    }
+
+    switch model.State {
+    case StateConfirm:
+        if press && msg.Button == tea.MouseButtonLeft {
+            switch labelAt(model.View(), msg.X, msg.Y, confirmButtons, "  ") {
+            case 0:
+                return model.confirmPending()
+            case 1:
+                return model.cancelPending()
+            }
+        }
+        return model, nil
+    case StateNormal, StateSearch:
+    default:
+        return model, nil
+    }
+
+    switch {
+    case !press:
+    case msg.Button == tea.MouseButtonWheelUp:
+        if model.inPreview(msg.X, msg.Y) {
+            model.Preview.ScrollUp(wheelLines)
+        } else {
+            model.moveCursor(-1)
+        }
+    case msg.Button == tea.MouseButtonWheelDown:
+        if model.inPreview(msg.X, msg.Y) {
+            model.Preview.ScrollDown(wheelLines)
+        } else {
+            model.moveCursor(1)
+        }
+    case msg.Button == tea.MouseButtonLeft:
+        if tab := labelAt(model.View(), msg.X, msg.Y, tabLabels(), " "); tab >= 0 {
+            model.ApplyFilter(filterTabs[tab].Filter)
+            return model, model.applySearch()
+        }
+        row, ok := model.rowAt(msg.X, msg.Y)
+        if !ok {
+            return model, nil
+        }
+        now := time.Now()
+        double := model.LastClick.Row == row && now.Sub(model.LastClick.At) < doubleClickTime
+        model.Cursor = row
+        model.LastClick = click{Row: row, At: now}
+        if !double {
+            return model, nil
+        }
+        model.LastClick = click{}
+        if model.TreeMode {
+            if node := model.selectedTreeNode(); node != nil && node.Note == nil {
+                model.Collapsed[node.Key] = !model.Collapsed[node.Key]
+                model.rebuildTree()
+                return model, nil
+            }
+        }
+        if note := model.selectedNote(); note != nil {
+            return model, model.openNote(note.ID)
+        }
+    }
+    return model, nil
+}
+
+func tabLabels() []string {
+    labels := make([]string, len(filterTabs))
+    for i, tab := range filterTabs {
+        labels[i] = tab.Label
+    }
+    return labels
+}
@@ -0,0 +1,100 @@
+package ui
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+)
+
+func TestLabelAt(t *testing.T) {
+    styled := lipgloss.NewStyle().Bold(true).Render(" Yes ")
+    view := "title\n│ 日本 " + styled + "   No  │\n"
+    labels := []string{"Yes", "No"}
+    tests := []struct {
+        x, y, want int
+    }{
+        // "│ 日本 " is 7 cells wide, wide runes counting twice
+        {7, 1, 0},
+        {11, 1, 0},
+        {12, 1, -1},
+        {14, 1, 1},
+        {17, 1, 1},
+        {18, 1, -1},
+        {6, 1, -1},
+        {7, 0, -1},
+        {7, 5, -1},
+        {7, -1, -1},
+    }
+    for _, test := range tests {
+        if got := labelAt(view, test.x, test.y, labels, "  "); got != test.want {
+            t.Errorf("labelAt(%d, %d) = %d, want %d", test.x, test.y, got, test.want)
+        }
+    }
+    if got := labelAt(view, 7, 1, []string{"Yes", "Maybe"}, "  "); got != -1 {
+        t.Errorf("labelAt with labels not on screen = %d, want -1", got)
+    }
+}
+
+func TestRowAt(t *testing.T) {
+    t.Setenv("HOME", t.TempDir())
+    t.Setenv("NO_COLOR", "1")
+    for _, test := range []struct {
+        name          string
+        height, count int
+        pinned        bool
+    }{
+        {"short list", 30, 3, false},
+        {"pinned", 30, 4, true},
+        {"scrolled", 16, 12, true},
+    } {
+        model, err := NewModel(nil, &config.Config{Compact: true},
+            gitctx.Static{Project: "p0", Branch: "main"}, FilterDisplayAll)
+        if err != nil {
+            t.Fatal(err)
+        }
+        notes := testNotes(test.count)
+        for _, note := range notes {
+            note.Title = "title " + note.ID
+        }
+        if test.pinned {
+            notes[1].Pinned = storage.PinProject
+        }
+        updated, _ := model.Update(tea.WindowSizeMsg{Width: 80, Height: test.height})
+        updated, _ = updated.(Model).Update(notesLoadedMsg{notes})
+        model = updated.(Model)
+        model.Cursor = test.count - 1
+        updated, _ = model.Update(nil)
+        model = updated.(Model)
+
+        found := 0
+        for y, line := range strings.Split(model.View(), "\n") {
+            row, ok := model.rowAt(4, y)
+            var want *storage.Note
+            for _, note := range model.DisplayedNotes {
+                if strings.Contains(line, note.Title+" ") || strings.HasSuffix(line, note.Title) {
+                    want = note
+                }
+            }
+            switch {
+            case want == nil && ok:
+                t.Errorf("%s: rowAt(4, %d) = %d on %q, want no row", test.name, y, row, line)
+            case want != nil && (!ok || model.DisplayedNotes[row] != want):
+                t.Errorf("%s: rowAt(4, %d) = %d, %v on %q", test.name, y, row, ok, line)
+            case want != nil:
+                found++
+            }
+        }
+        if found == 0 || (test.height == 30 && found != test.count) {
+            t.Errorf("%s: %d rows hit, out of %d notes", test.name, found, test.count)
+        }
+        if _, ok := model.rowAt(80, 5); ok {
+            t.Errorf("%s: row found right of the list", test.name)
+        }
+    }
+}
@@ -0,0 +1,555 @@
+package ui
+
+import (
+    "errors"
+    "fmt"
+    "slices"
+    "sort"
+    "strings"
+
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+    "github.com/JonLD/jot/themes"
+
+    "github.com/charmbracelet/bubbles/key"
+    "github.com/charmbracelet/bubbles/textinput"
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/sahilm/fuzzy"
+)
+
+// How many commands the palette remembers, and how many rows it shows
+const (
+    maxRecentCommands = 8
+    paletteRows       = 10
+)
+
+// paletteCommand is an action run from the command palette as
+// "name args". args is a hint, "<...>" when an argument is required and
+// "[...]" when it is optional; complete offers values for it, which are the
+// only valid ones when exact is set.
+type paletteCommand struct {
+    name     string
+    args     string
+    help     string
+    exact    bool
+    complete func(model Model) []string
+    run      func(model Model, args string) (tea.Model, tea.Cmd)
+}
+
+// paletteItem is a row of the palette: a command, a recent command line or
+// a completed argument
+type paletteItem struct {
+    Line   string
+    Help   string
+    Recent bool
+}
+
+// palette is the command palette's input and the rows matching it
+type palette struct {
+    Input  textinput.Model
+    Items  []paletteItem
+    Cursor int
+}
+
+var paletteCommands = []paletteCommand{
+    {"filter", "<branch|project|all>", "show branch, project or all notes", true,
+        func(Model) []string { return []string{"branch", "project", "all"} },
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            filters := map[string]FilterFunc{
+                "branch": FilterByBranch, "project": FilterByProject, "all": FilterDisplayAll,
+            }
+            filter, ok := filters[args]
+            if !ok {
+                model.fail(fmt.Errorf("unknown filter: %s", args))
+                return model, nil
+            }
+            model.ApplyFilter(filter)
+            return model, model.applySearch()
+        }},
+    {"sort", "<mode>", "sort the list", true,
+        func(Model) []string {
+            var modes []string
+            for _, mode := range storage.SortModes {
+                modes = append(modes, string(mode))
+            }
+            return modes
+        },
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            mode, err := storage.ParseSortMode(args)
+            if err != nil {
+                model.fail(err)
+                return model, nil
+            }
+            model.setSort(mode)
+            return model, nil
+        }},
+    {"search", "[query]", "search titles, with qualifiers like tag:perf", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            model.State = StateSearch
+            model.SearchInputText.SetValue(args)
+            return model, tea.Batch(model.SearchInputText.Focus(), model.applySearch())
+        }},
+    {"content", "", "switch between title and content search", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model, model.toggleContentSearch()
+        }},
+    {"new", "[project[/subproject][:branch]]", "new note, here or in another scope", false, scopeCompletions,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            template := storage.Note{
+                Project: model.Scope.Project, SubProject: model.Scope.SubProject, Branch: model.Scope.Branch,
+            }
+            for _, opt := range moveOptions(&template, args) {
+                opt(&template)
+            }
+            scope := template.Scope()
+            return model.startNewNote(scope, model.Repo.TicketFor(scope))
+        }},
+    {"copy", "[project[/subproject][:branch]]", "copy the selected note, here or to another scope", false, scopeCompletions,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            template := storage.Note{
+                Project: model.Scope.Project, SubProject: model.Scope.SubProject, Branch: model.Scope.Branch,
+            }
+            for _, opt := range moveOptions(&template, args) {
+                opt(&template)
+            }
+            return model.startCopy(template.Scope())
+        }},
+    {"edit", "", "edit the selected note's title, scope and tags", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startEdit()
+        }},
+    {"append", "[text]", "append a timestamped entry to the selected note", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            if args == "" {
+                return model.startAppend()
+            }
+            note := model.selectedNote()
+            if note == nil {
+                model.fail(errors.New("no note selected"))
+                return model, nil
+            }
+            return model.appendEntry(note.ID, note.Title, args)
+        }},
+    {"quick-edit", "", "edit the selected note's text inside jot", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startInlineEdit()
+        }},
+    {"move", "<project[/subproject][:branch]>", "move the marked notes", false, scopeCompletions,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startBulkActionWith(bulkMove, args)
+        }},
+    {"ticket", "[ticket]", "set the marked notes' ticket, empty to clear", false,
+        func(model Model) []string {
+            return model.noteValues(func(note *storage.Note) []string { return []string{note.Ticket} })
+        },
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startBulkActionWith(bulkSetTicket, args)
+        }},
+    {"tag", "<tag>", "tag the marked notes", false, tagCompletions,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startBulkActionWith(bulkAddTag, args)
+        }},
+    {"untag", "<tag>", "remove a tag from the marked notes", false, tagCompletions,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startBulkActionWith(bulkRemoveTag, args)
+        }},
+    {"pin", "<project|global>", "pin the marked notes in their project or everywhere", true,
+        func(Model) []string { return []string{string(storage.PinProject), string(storage.PinGlobal)} },
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            pin := storage.Pin(args)
+            if pin != storage.PinProject && pin != storage.PinGlobal {
+                model.fail(fmt.Errorf("unknown pin: %s", args))
+                return model, nil
+            }
+            return model.pinNotes(model.actionTargets(), pin)
+        }},
+    {"unpin", "", "unpin the marked notes", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.pinNotes(model.actionTargets(), storage.PinNone)
+        }},
+    {"archive", "", "archive or restore the marked notes", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startBulkAction(bulkArchive)
+        }},
+    {"delete", "", "delete the marked notes", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model.startBulkAction(bulkDelete)
+        }},
+    {"export", "", "export the marked notes' commit links to " + gitctx.NotesRef, false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            notes := model.actionTargets()
+            if err := model.exportCommits(notes); err != nil {
+                model.fail(err)
+            } else {
+                model.notify("Exported the commit links of %s", countNotes(notes))
+            }
+            model.clearSelection()
+            return model, nil
+        }},
+    {"mark-all", "", "mark every displayed note", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            model.toggleSelectAll()
+            return model, nil
+        }},
+    {"archived", "", "show or hide archived notes", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            model.ShowArchived = !model.ShowArchived
+            model.ApplyFilter(model.CurrentFilter)
+            return model, model.applySearch()
+        }},
+    {"tree", "", "switch between the list and the tree", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            model.toggleTree()
+            return model, nil
+        }},
+    {"preview", "", "show or hide the preview", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            model.togglePreview()
+            return model, nil
+        }},
+    {"theme", "<name>", "switch and save the theme", true,
+        func(model Model) []string {
+            registry := themes.NewRegistry()
+            if dir, err := themes.UserDir(); err == nil {
+                registry.LoadDir(dir)
+            }
+            return append([]string{themes.Auto}, registry.Names()...)
+        },
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            if err := model.setTheme(args); err != nil {
+                model.fail(err)
+            } else {
+                model.notify("Switched to the %s theme", args)
+            }
+            return model, nil
+        }},
+    {"help", "", "list every key", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            model.ShowHelp = true
+            return model, nil
+        }},
+    {"quit", "", "quit jot", false, nil,
+        func(model Model, args string) (tea.Model, tea.Cmd) {
+            return model, tea.Quit
+        }},
+}
+
+func findPaletteCommand(name string) (paletteCommand, bool) {
+    for _, command := range paletteCommands {
+        if command.name == name {
+            return command, true
+        }
+    }
+    return paletteCommand{}, false
+}
+
+func newPaletteInput() textinput.Model {
+    input := textinput.New()
+    input.CharLimit = 200
+    input.Width = 40
+    input.Placeholder = "Command"
+    return input
+}
+
+// startPalette opens the palette with an empty line, listing recent
+// commands first
+func (model Model) startPalette() (tea.Model, tea.Cmd) {
+    model.State = StatePalette
+    model.Palette.Input.Reset()
+    model.Palette.Cursor = 0
+    model.refreshPalette()
+    return model, model.Palette.Input.Focus()
+}
+
+// refreshPalette lists the rows for the palette's line: recent and all
+// commands when it is empty, commands fuzzy matching the name being typed,
+// or the command's argument values once a space follows its name
+func (model *Model) refreshPalette() {
+    line := model.Palette.Input.Value()
+    var items []paletteItem
+
+    name, args, hasArgs := strings.Cut(line, " ")
+    command, known := findPaletteCommand(name)
+    switch {
+    case strings.TrimSpace(line) == "":
+        for _, recent := range model.Config.RecentCommands {
+            items = append(items, paletteItem{Line: recent, Help: "recent", Recent: true})
+        }
+        for _, command := range paletteCommands {
+            items = append(items, paletteItem{Line: command.name, Help: command.help})
+        }
+    case hasArgs && known:
+        var values []string
+        if command.complete != nil {
+            values = command.complete(*model)
+        }
+        args = strings.TrimSpace(args)
+        if args != "" {
+            matches := fuzzy.Find(args, values)
+            values = nil
+            for _, match := range matches {
+                values = append(values, match.Str)
+            }
+            // Typed values come first, unless only the offered ones will do
+            if !slices.Contains(values, args) && !(command.exact && len(values) > 0) {
+                values = append([]string{args}, values...)
+            }
+        }
+        for _, value := range values {
+            items = append(items, paletteItem{Line: command.name + " " + value, Help: command.help})
+        }
+        if len(items) == 0 {
+            items = append(items, paletteItem{Line: strings.TrimSpace(line), Help: command.help})
+        }
+    default:
+        // Commands whose name matches come before those whose help does
+        names := make([]string, len(paletteCommands))
+        helps := make([]string, len(paletteCommands))
+        for i, command := range paletteCommands {
+            names[i] = command.name
+            helps[i] = command.help
+        }
+        listed := make(map[int]bool)
+        for _, matches := range []fuzzy.Matches{
+            fuzzy.Find(strings.TrimSpace(line), names),
+            fuzzy.Find(strings.TrimSpace(line), helps),
+        } {
+            for _, match := range matches {
+                if !listed[match.Index] {
+                    listed[match.Index] = true
+                    command := paletteCommands[match.Index]
+                    items = append(items, paletteItem{Line: command.name, Help: command.help})
+                }
+            }
+        }
+    }
+
+    model.Palette.Items = items
+    model.Palette.Cursor = max(min(model.Palette.Cursor, len(items)-1), 0)
+}
+
+func (model Model) updatePaletteMode(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.Cancel):
+        model.State = StateNormal
+        model.Palette.Input.Blur()
+        return model, nil
+    case key.Matches(msg, keys.PaletteUp):
+        model.Palette.Cursor = max(model.Palette.Cursor-1, 0)
+        return model, nil
+    case key.Matches(msg, keys.PaletteDown):
+        model.Palette.Cursor = min(model.Palette.Cursor+1, max(len(model.Palette.Items)-1, 0))
+        return model, nil
+    case key.Matches(msg, keys.PaletteComplete):
+        if item, ok := model.selectedPaletteItem(); ok {
+            model.completePalette(item)
+        }
+        return model, nil
+    case key.Matches(msg, keys.Submit):
+        line := strings.TrimSpace(model.Palette.Input.Value())
+        if item, ok := model.selectedPaletteItem(); ok {
+            // A command that needs an argument asks for it first
+            if command, known := findPaletteCommand(item.Line); known &&
+                strings.HasPrefix(command.args, "<") && !item.Recent {
+                model.completePalette(item)
+                return model, nil
+            }
+            line = item.Line
+        }
+        return model.runPaletteLine(line)
+    }
+
+    var cmd tea.Cmd
+    model.Palette.Input, cmd = model.Palette.Input.Update(msg)
+    model.Palette.Cursor = 0
+    model.refreshPalette()
+    return model, cmd
+}
+
+func (model Model) selectedPaletteItem() (paletteItem, bool) {
+    if model.Palette.Cursor >= len(model.Palette.Items) {
+        return paletteItem{}, false
+    }
+    return model.Palette.Items[model.Palette.Cursor], true
+}
+
+// completePalette copies a row into the line, followed by a space when its
+// command takes arguments
+func (model *Model) completePalette(item paletteItem) {
+    line := item.Line
+    if command, known := findPaletteCommand(line); known && command.args != "" {
+        line += " "
+    }
+    model.Palette.Input.SetValue(line)
+    model.Palette.Input.CursorEnd()
+    model.Palette.Cursor = 0
+    model.refreshPalette()
+}
+
+// runPaletteLine runs "name args", remembering it as a recent command
+func (model Model) runPaletteLine(line string) (tea.Model, tea.Cmd) {
+    model.State = StateNormal
+    model.Palette.Input.Blur()
+
+    name, args, _ := strings.Cut(line, " ")
+    args = strings.TrimSpace(args)
+    command, known := findPaletteCommand(name)
+    if !known {
+        model.fail(fmt.Errorf("unknown command: %s", name))
+        return model, nil
+    }
+    if args == "" && strings.HasPrefix(command.args, "<") {
+        model.fail(fmt.Errorf("%s needs an argument: %s %s", name, name, command.args))
+        return model, nil
+    }
+
+    updated, cmd := command.run(model, args)
+    if ran, ok := updated.(Model); ok && !ran.failed(model) {
+        ran.rememberCommand(strings.TrimSpace(name + " " + args))
+        updated = ran
+    }
+    return updated, cmd
+}
+
+// rememberCommand moves line to the front of the recent commands and saves
+// them with the config
+func (model *Model) rememberCommand(line string) {
+    recent := slices.DeleteFunc(slices.Clone(model.Config.RecentCommands), func(previous string) bool {
+        return previous == line
+    })
+    recent = append([]string{line}, recent...)
+    if len(recent) > maxRecentCommands {
+        recent = recent[:maxRecentCommands]
+    }
+    model.Config.RecentCommands = recent
+    if err := model.Config.Save(); err != nil {
+        model.fail(fmt.Errorf("error saving recent commands: %w", err))
+    }
+}
+
+// startBulkActionWith starts an action that takes a value, given up front,
+// so only the confirmation is left
+func (model Model) startBulkActionWith(kind bulkKind, value string) (tea.Model, tea.Cmd) {
+    updated, cmd := model.startBulkAction(kind)
+    started := updated.(Model)
+    if started.State == StateBulkInput {
+        started.BulkInputText.Blur()
+        started.Pending.Value = value
+        started.State = StateConfirm
+    }
+    return started, cmd
+}
+
+// exportCommits adds a pointer to each note in refs/notes/jot on the
+// commits it is linked to
+func (model Model) exportCommits(notes []*storage.Note) error {
+    if !model.Repo.IsRepo() {
+        return errors.New("not a git repository")
+    }
+    for _, note := range notes {
+        commits, err := model.Store.GetCommits(note.ID)
+        if err != nil {
+            return fmt.Errorf("error fetching commits: %w", err)
+        }
+        for _, sha := range commits {
+            if err := gitctx.AddNote(model.Repo.Root, sha, note.GitNoteMessage()); err != nil {
+                return fmt.Errorf("error exporting git note: %w", err)
+            }
+        }
+    }
+    return nil
+}
+
+// setTheme switches to a theme by name and saves it as the configured one
+func (model *Model) setTheme(name string) error {
+    cfg := *model.Config
+    cfg.Theme = name
+    // Broken theme files were reported when the TUI started
+    scheme, _, err := LoadTheme(&cfg)
+    if err != nil {
+        return err
+    }
+    model.Styles = NewStyles(scheme)
+    model.Help = newHelp(model.Styles)
+    // Re-render the preview in the new colors
+    model.PreviewNoteID = ""
+
+    model.Config.Theme = name
+    if err := model.Config.Save(); err != nil {
+        return fmt.Errorf("error saving theme: %w", err)
+    }
+    return nil
+}
+
+// noteValues lists the distinct non-empty values of every note, sorted
+func (model Model) noteValues(values func(note *storage.Note) []string) []string {
+    seen := make(map[string]bool)
+    var distinct []string
+    for _, note := range model.Notes {
+        for _, value := range values(note) {
+            if value != "" && !seen[value] {
+                seen[value] = true
+                distinct = append(distinct, value)
+            }
+        }
+    }
+    sort.Strings(distinct)
+    return distinct
+}
+
+func scopeCompletions(model Model) []string {
+    return model.noteValues(func(note *storage.Note) []string {
+        return []string{note.Scope().Label() + ":" + note.Branch}
+    })
+}
+
+func tagCompletions(model Model) []string {
+    return model.noteValues(func(note *storage.Note) []string { return note.Tags })
+}
+
+// paletteView is the palette popup: the line being typed and the rows
+// matching it, scrolled to keep the selected row visible
+func (model Model) paletteView() string {
+    width := model.popupWidth(64)
+    contentWidth := width - 6
+
+    var rows strings.Builder
+    start := max(model.Palette.Cursor-paletteRows+1, 0)
+    end := min(start+paletteRows, len(model.Palette.Items))
+    for i := start; i < end; i++ {
+        item := model.Palette.Items[i]
+        line := item.Line
+        if command, known := findPaletteCommand(line); known && command.args != "" && !item.Recent {
+            line += " " + command.args
+        }
+        row := truncate(line+"  "+model.Styles.Muted.Render(item.Help), contentWidth-2)
+        if i == model.Palette.Cursor {
+            rows.WriteString(model.Styles.Selected.Render("▶ ") + row + "\n")
+        } else {
+            rows.WriteString("  " + row + "\n")
+        }
+    }
+    if len(model.Palette.Items) == 0 {
+        rows.WriteString(model.Styles.Muted.Render("No matching command") + "\n")
+    }
+
+    // Leave room for the ellipsis help adds, as in helpView
+    model.Help.Width = max(contentWidth-2, 1)
+    hints := truncate(model.Help.ShortHelpView([]key.Binding{
+        model.Keys.PaletteUp, model.Keys.PaletteDown, model.Keys.PaletteComplete,
+        key.NewBinding(key.WithKeys(model.Keys.Submit.Keys()...),
+            key.WithHelp(model.Keys.Submit.Help().Key, "run")),
+        model.Keys.Cancel,
+    }), contentWidth)
+
+    return model.Styles.Popup.
+        Padding(1, 2).
+        Width(width).
+        Render(
+        model.Styles.Primary.Bold(true).Render("Commands") + "\n\n" +
+        model.Palette.Input.View() + "\n\n" +
+        rows.String() + "\n" +
+        hints,
+        )
+}
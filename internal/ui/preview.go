@@ -0,0 +1,183 @@
+package ui
+
+import (
+    "os"
+    "regexp"
+    "strings"
+
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/charmbracelet/lipgloss"
+)
+
+// Terminals narrower than this stack the preview under the list
+const splitMinWidth = 100
+
+var (
+    inlineCodePattern = regexp.MustCompile("`[^`]+`")
+    boldPattern       = regexp.MustCompile(`\*\*[^*]+\*\*|__[^_]+__`)
+    listItemPattern   = regexp.MustCompile(`^(\s*)([-*+]|\d+\.)\s+(.*)$`)
+    checkboxPattern   = regexp.MustCompile(`^\[([ xX])\]\s+(.*)$`)
+)
+
+// selectedNote returns the note under the cursor, or nil when the list is
+// empty or a tree group is selected
+func (model Model) selectedNote() *storage.Note {
+    if model.TreeMode {
+        if node := model.selectedTreeNode(); node != nil {
+            return node.Note
+        }
+        return nil
+    }
+    if model.Cursor < 0 || model.Cursor >= len(model.DisplayedNotes) {
+        return nil
+    }
+    return model.DisplayedNotes[model.Cursor]
+}
+
+// syncPreview loads the selected note into the preview when the selection
+// has changed
+func (model *Model) syncPreview() {
+    if !model.ShowPreview {
+        return
+    }
+    note := model.selectedNote()
+    if note == nil {
+        model.PreviewNoteID = ""
+        model.Preview.SetContent(model.Styles.Muted.Render("No note selected"))
+        return
+    }
+    if note.ID == model.PreviewNoteID {
+        return
+    }
+    model.PreviewNoteID = note.ID
+    model.refreshPreview()
+}
+
+// refreshPreview renders the selected note's file at the current preview width
+func (model *Model) refreshPreview() {
+    note := model.selectedNote()
+    if note == nil {
+        return
+    }
+    content, err := os.ReadFile(note.Path)
+    if err != nil {
+        model.Preview.SetContent(model.Styles.Error.Render("Cannot read note: " + err.Error()))
+    } else {
+        model.Preview.SetContent(renderMarkdown(model.Styles, string(content), model.Preview.Width))
+    }
+    model.Preview.GotoTop()
+}
+
+// layoutPreview sizes the preview for the window. Wide terminals put it to
+// the right of the list, narrow ones below it.
+func (model *Model) layoutPreview() {
+    width, height := model.windowSize()
+    if width >= splitMinWidth {
+        // Borders and padding take 4 columns; borders and the title 3 rows
+        model.Preview.Width = width - width*2/5 - 4
+        model.Preview.Height = height - 3
+    } else {
+        model.Preview.Width = width - 4
+        model.Preview.Height = height/3 - 3
+    }
+    if model.Preview.Height < 3 {
+        model.Preview.Height = 3
+    }
+    if model.ShowPreview && model.PreviewNoteID != "" {
+        model.refreshPreview()
+    }
+}
+
+// windowSize returns the size of the terminal above the status bar,
+// assuming an 80x24 terminal until Bubble Tea has reported it
+func (model Model) windowSize() (int, int) {
+    width, height := model.Width, model.Height
+    if width == 0 {
+        width = 80
+    }
+    if height == 0 {
+        height = 24
+    }
+    return width, height - statusBarHeight
+}
+
+func (model Model) previewView() string {
+    title := "Preview"
+    if note := model.selectedNote(); note != nil {
+        title = note.Title
+    }
+    header := model.Styles.Primary.Bold(true).Render(truncate(title, model.Preview.Width))
+    return model.Styles.Window.
+        Padding(0, 1).
+        Render(header + "\n" + model.Preview.View())
+}
+
+// renderMarkdown styles the subset of markdown notes commonly use: headings,
+// lists and checkboxes, quotes, rules, fenced code and inline code/bold
+func renderMarkdown(styles Styles, src string, width int) string {
+    if width < 10 {
+        width = 10
+    }
+    headingStyle := styles.Primary.Bold(true)
+    codeStyle := styles.Code
+    textStyle := styles.Text
+    wrap := lipgloss.NewStyle().Width(width)
+
+    var out []string
+    inFence := false
+    for _, line := range strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n") {
+        trimmed := strings.TrimSpace(line)
+
+        if strings.HasPrefix(trimmed, "```") {
+            inFence = !inFence
+            out = append(out, styles.Muted.Render(strings.Repeat("─", min(width, 20))))
+            continue
+        }
+        if inFence {
+            out = append(out, codeStyle.Render("  "+line))
+            continue
+        }
+
+        switch {
+        case strings.HasPrefix(trimmed, "#"):
+            heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+            out = append(out, wrap.Render(headingStyle.Render(heading)))
+        case trimmed == "---" || trimmed == "***" || trimmed == "___":
+            out = append(out, styles.Muted.Render(strings.Repeat("─", width)))
+        case strings.HasPrefix(trimmed, ">"):
+            quote := strings.TrimSpace(strings.TrimPrefix(trimmed, ">"))
+            out = append(out, wrap.Render(styles.Muted.Render("│ "+quote)))
+        default:
+            if m := listItemPattern.FindStringSubmatch(line); m != nil {
+                bullet, item := "• ", m[3]
+                if m[2] != "-" && m[2] != "*" && m[2] != "+" {
+                    bullet = m[2] + " "
+                }
+                if c := checkboxPattern.FindStringSubmatch(item); c != nil {
+                    bullet, item = "☐ ", c[2]
+                    if c[1] != " " {
+                        bullet = "☑ "
+                        item = styles.Muted.Strikethrough(true).Render(c[2])
+                    }
+                }
+                line = m[1] + styles.Primary.Render(bullet) + renderInline(item, textStyle, codeStyle)
+                out = append(out, wrap.Render(line))
+                continue
+            }
+            out = append(out, wrap.Render(renderInline(line, textStyle, codeStyle)))
+        }
+    }
+    return strings.Join(out, "\n")
+}
+
+// renderInline styles `code` and **bold** spans within a line
+func renderInline(line string, textStyle, codeStyle lipgloss.Style) string {
+    line = inlineCodePattern.ReplaceAllStringFunc(line, func(code string) string {
+        return codeStyle.Render(strings.Trim(code, "`"))
+    })
+    line = boldPattern.ReplaceAllStringFunc(line, func(bold string) string {
+        return textStyle.Bold(true).Render(bold[2 : len(bold)-2])
+    })
+    return line
+}
@@ -0,0 +1,39 @@
+package ui
+
+import (
+    "strings"
+    "testing"
+
+    "github.com/JonLD/jot/themes"
+
+    "github.com/charmbracelet/x/ansi"
+)
+
+func TestRenderMarkdown(t *testing.T) {
+    styles := NewStyles(themes.ColorScheme{})
+    tests := []struct {
+        name, src, want string
+    }{
+        {"heading", "## Plan", "Plan"},
+        {"rule", "---", "────────────"},
+        {"quote", "> said so", "│ said so"},
+        {"bullets", "- one\n  * two", "• one\n  • two"},
+        {"numbered", "1. first", "1. first"},
+        {"checkboxes", "- [ ] open\n- [x] done", "☐ open\n☑ done"},
+        {"inline", "a `b` **c**", "a b c"},
+        {"fence", "```\n# not a heading\n```", "────────────\n  # not a heading\n────────────"},
+        {"wrapped", "one two three four", "one two\nthree four"},
+        {"crlf", "# A\r\nb", "A\nb"},
+    }
+    for _, test := range tests {
+        // Lines are padded to the width
+        lines := strings.Split(ansi.Strip(renderMarkdown(styles, test.src, 12)), "\n")
+        for i, line := range lines {
+            lines[i] = strings.TrimRight(line, " ")
+        }
+        got := strings.Join(lines, "\n")
+        if got != test.want {
+            t.Errorf("%s: got\n%q\nwant\n%q", test.name, got, test.want)
+        }
+    }
+}
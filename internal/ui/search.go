@@ -0,0 +1,236 @@
+package ui
+
+import (
+    "fmt"
+    "os"
+    "strings"
+    "time"
+    "unicode"
+
+    "github.com/JonLD/jot/internal/storage"
+
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+// Content search waits for typing to pause this long before reading files
+const searchDebounce = 150 * time.Millisecond
+
+// contentMatch is the first line of a note's body that matches a content
+// search
+type contentMatch struct {
+    Line    int    // 1-based line number in the file
+    Text    string // the line, trimmed
+    Indexes []int  // rune offsets of the matched characters in Text
+}
+
+// searchTickMsg fires once typing has paused. Only the tick of the latest
+// query starts a search.
+type searchTickMsg struct {
+    seq int
+}
+
+// contentResultsMsg carries the notes matching a content search, in list
+// order
+type contentResultsMsg struct {
+    seq     int
+    notes   []*storage.Note
+    matches map[string]contentMatch
+}
+
+// showsMatches reports whether list rows carry a second line with the
+// matching line of the note
+func (model Model) showsMatches() bool {
+    return model.ContentSearch && !model.TreeMode && model.Query.Text != ""
+}
+
+// rowHeight is the number of lines each entry of the list takes
+func (model Model) rowHeight() int {
+    if model.showsMatches() {
+        return 2
+    }
+    return 1
+}
+
+// applySearch narrows the filtered notes to those matching the search's
+// qualifiers and text. Titles are matched straight away; content search is
+// debounced and runs off the update loop, showing the previous results in
+// the meantime.
+func (model *Model) applySearch() tea.Cmd {
+    model.SearchSeq++
+    model.Query, model.QueryErr = storage.ParseQuery(model.SearchInputText.Value())
+
+    if !model.ContentSearch || model.Query.Text == "" {
+        model.Matches = nil
+        model.setDisplayed(model.pinnedFirst(model.Query.Filter(model.FilteredNotes)))
+        return nil
+    }
+
+    var notes []*storage.Note
+    for _, note := range model.FilteredNotes {
+        if _, ok := model.Matches[note.ID]; ok && model.Query.Matches(note) {
+            notes = append(notes, note)
+        }
+    }
+    model.setDisplayed(notes)
+    seq := model.SearchSeq
+    return tea.Tick(searchDebounce, func(time.Time) tea.Msg {
+        return searchTickMsg{seq}
+    })
+}
+
+// toggleContentSearch switches between searching titles and note contents
+func (model *Model) toggleContentSearch() tea.Cmd {
+    model.ContentSearch = !model.ContentSearch
+    model.Matches = nil
+    return model.applySearch()
+}
+
+// updateSearchTick starts the content search once typing has paused
+func (model Model) updateSearchTick(msg searchTickMsg) (tea.Model, tea.Cmd) {
+    if msg.seq != model.SearchSeq || !model.ContentSearch {
+        return model, nil
+    }
+    query := model.Query
+    notes := model.FilteredNotes
+    return model, func() tea.Msg {
+        results := contentResultsMsg{seq: msg.seq, matches: make(map[string]contentMatch)}
+        for _, note := range notes {
+            if !query.Matches(note) {
+                continue
+            }
+            if match, ok := searchNote(note.Path, query.Text); ok {
+                results.notes = append(results.notes, note)
+                results.matches[note.ID] = match
+            }
+        }
+        return results
+    }
+}
+
+// updateContentResults shows the results of the latest content search,
+// keeping the cursor on the same note
+func (model Model) updateContentResults(msg contentResultsMsg) (tea.Model, tea.Cmd) {
+    if msg.seq != model.SearchSeq {
+        return model, nil
+    }
+    selected := model.selectedNote()
+    model.setDisplayed(msg.notes)
+    model.Matches = msg.matches
+    if !model.TreeMode && selected != nil {
+        for i, note := range model.DisplayedNotes {
+            if note.ID == selected.ID {
+                model.Cursor = i
+            }
+        }
+    }
+    return model, nil
+}
+
+// searchNote finds the first line below the note's header containing query,
+// ignoring case
+func searchNote(path string, query string) (contentMatch, bool) {
+    content, err := os.ReadFile(path)
+    if err != nil {
+        return contentMatch{}, false
+    }
+    needle := []rune(strings.ToLower(query))
+    lines := strings.Split(string(content), "\n")
+    for i := bodyStart(lines); i < len(lines); i++ {
+        text := strings.ReplaceAll(strings.TrimSpace(lines[i]), "\t", " ")
+        if start := indexFold([]rune(text), needle); start >= 0 {
+            indexes := make([]int, len(needle))
+            for j := range indexes {
+                indexes[j] = start + j
+            }
+            return contentMatch{Line: i + 1, Text: text, Indexes: indexes}, true
+        }
+    }
+    return contentMatch{}, false
+}
+
+// bodyStart skips the title and metadata jot writes above the first "---"
+// line, so searching for "project" doesn't match every note
+func bodyStart(lines []string) int {
+    if len(lines) == 0 || !strings.HasPrefix(lines[0], "# ") {
+        return 0
+    }
+    for i, line := range lines {
+        if strings.TrimSpace(line) == "---" {
+            return i + 1
+        }
+    }
+    return 0
+}
+
+// indexFold returns the rune offset of the first occurrence of the lower
+// case needle in text, ignoring case, or -1
+func indexFold(text []rune, needle []rune) int {
+    for start := 0; start+len(needle) <= len(text); start++ {
+        found := true
+        for j, r := range needle {
+            if unicode.ToLower(text[start+j]) != r {
+                found = false
+                break
+            }
+        }
+        if found {
+            return start
+        }
+    }
+    return -1
+}
+
+// chipsView shows the search's qualifiers, negated ones in the error color,
+// followed by any that don't parse
+func (model Model) chipsView(width int) string {
+    var chips []string
+    for _, filter := range model.Query.Filters {
+        style := model.Styles.Primary
+        if filter.Negate {
+            style = model.Styles.Error
+        }
+        chips = append(chips, style.Reverse(true).Padding(0, 1).Render(filter.String()))
+    }
+    if model.QueryErr != nil {
+        chips = append(chips, model.Styles.Error.Render(strings.ReplaceAll(model.QueryErr.Error(), "\n", "; ")))
+    }
+    return truncate(strings.Join(chips, " "), width)
+}
+
+// matchLineView renders the matching line under a content search result,
+// scrolled so the match is visible and with the matched characters
+// highlighted
+func (model Model) matchLineView(match contentMatch, width int) string {
+    label := fmt.Sprintf("    %d: ", match.Line)
+    text := []rune(match.Text)
+    available := width - len(label)
+
+    matched := make(map[int]bool)
+    for _, index := range match.Indexes {
+        matched[index] = true
+    }
+    start := 0
+    if len(match.Indexes) > 0 && match.Indexes[len(match.Indexes)-1] >= available-1 {
+        start = max(match.Indexes[0]-available/3, 0)
+    }
+
+    var line strings.Builder
+    line.WriteString(model.Styles.Muted.Render(label))
+    if start > 0 {
+        line.WriteString(model.Styles.Muted.Render("…"))
+    }
+    highlight := model.Styles.Primary.Bold(true)
+    for i := start; i < len(text); {
+        end := i
+        for end < len(text) && matched[end] == matched[i] {
+            end++
+        }
+        if matched[i] {
+            line.WriteString(highlight.Render(string(text[i:end])))
+        } else {
+            line.WriteString(model.Styles.Muted.Render(string(text[i:end])))
+        }
+        i = end
+    }
+    return truncate(line.String(), width)
+}
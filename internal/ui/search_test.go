@@ -0,0 +1,37 @@
+package ui
+
+import (
+    "fmt"
+    "os"
+    "path/filepath"
+    "testing"
+)
+
+func TestSearchNote(t *testing.T) {
+    dir := t.TempDir()
+    header := "# Plan\n\nProject: api\nBranch: main\n\n---\n\n"
+    tests := []struct {
+        name, content, query string
+        want                 contentMatch
+        found                bool
+    }{
+        {"body line", header + "intro\n  Fix the API\n", "api", contentMatch{9, "Fix the API", []int{8, 9, 10}}, true},
+        {"header skipped", header + "nothing\n", "project", contentMatch{}, false},
+        {"no header", "notes on the project\n", "PROJECT", contentMatch{1, "notes on the project", []int{13, 14, 15, 16, 17, 18, 19}}, true},
+        {"first match", header + "one todo\ntwo todo\n", "todo", contentMatch{8, "one todo", []int{4, 5, 6, 7}}, true},
+        {"runes and tabs", header + "\tÉté\tcafé\n", "CAFÉ", contentMatch{8, "Été café", []int{4, 5, 6, 7}}, true},
+    }
+    for i, test := range tests {
+        path := filepath.Join(dir, fmt.Sprintf("%d.md", i))
+        if err := os.WriteFile(path, []byte(test.content), 0644); err != nil {
+            t.Fatal(err)
+        }
+        match, found := searchNote(path, test.query)
+        if found != test.found || fmt.Sprint(match) != fmt.Sprint(test.want) {
+            t.Errorf("%s: searchNote = %v, %v, want %v, %v", test.name, match, found, test.want, test.found)
+        }
+    }
+    if _, found := searchNote(filepath.Join(dir, "missing.md"), "a"); found {
+        t.Error("match found in a missing file")
+    }
+}
@@ -0,0 +1,107 @@
+package ui
+
+import (
+    "fmt"
+    "strings"
+    "time"
+
+    tea "github.com/charmbracelet/bubbletea"
+    "github.com/charmbracelet/lipgloss"
+)
+
+// The status bar takes the last line of the terminal
+const statusBarHeight = 1
+
+// How long toasts stay in the status bar. Errors stay longer so there is
+// time to read them.
+const (
+    toastDuration      = 3 * time.Second
+    errorToastDuration = 6 * time.Second
+)
+
+// toast is a message shown in the status bar for a while after an action
+type toast struct {
+    Text string
+    Err  bool
+    Seq  int
+}
+
+// toastExpiredMsg clears the toast it was scheduled for, unless another one
+// has replaced it since
+type toastExpiredMsg struct {
+    seq int
+}
+
+// notify shows a success toast
+func (model *Model) notify(format string, args ...any) {
+    model.Toast = toast{Text: fmt.Sprintf(format, args...), Seq: model.Toast.Seq + 1}
+}
+
+// fail shows err as an error toast, doing nothing when it is nil
+func (model *Model) fail(err error) {
+    if err != nil {
+        model.Toast = toast{Text: err.Error(), Err: true, Seq: model.Toast.Seq + 1}
+    }
+}
+
+// failed reports whether an error toast was shown since previous
+func (model Model) failed(previous Model) bool {
+    return model.Toast.Err && model.Toast.Seq != previous.Toast.Seq
+}
+
+// expireToast schedules the current toast's removal
+func (model Model) expireToast() tea.Cmd {
+    duration := toastDuration
+    if model.Toast.Err {
+        duration = errorToastDuration
+    }
+    seq := model.Toast.Seq
+    return tea.Tick(duration, func(time.Time) tea.Msg {
+        return toastExpiredMsg{seq}
+    })
+}
+
+// filterLabel describes the current filter with the scope it resolved to
+func (model Model) filterLabel() string {
+    switch {
+    case sameFilter(model.CurrentFilter, FilterByBranch):
+        return "branch " + model.Scope.Label() + ":" + model.Scope.Branch
+    case sameFilter(model.CurrentFilter, FilterByProject):
+        return "project " + model.Scope.Label()
+    }
+    return "all notes"
+}
+
+// statusView is the bar at the bottom of the terminal: the git context and
+// ticket, the filter and sort, the latest toast and how many notes are shown
+func (model Model) statusView(width int) string {
+    context := model.Repo.String()
+    if ticket := model.Repo.TicketFor(model.Scope); ticket != "" {
+        context += " · " + ticket
+    }
+    details := model.filterLabel() + " · sort: " + model.Sort.Description()
+    if model.ShowArchived {
+        details += " · showing archived"
+    }
+    left := model.Styles.Primary.Reverse(true).Padding(0, 1).Render(context) + " " +
+        model.Styles.Muted.Render(details)
+
+    right := model.Styles.Muted.Render(fmt.Sprintf(" %d/%d notes", len(model.DisplayedNotes), len(model.Notes)))
+    if model.Toast.Text != "" {
+        style, mark := model.Styles.Primary, "✓ "
+        if model.Toast.Err {
+            style, mark = model.Styles.Error, "✗ "
+        }
+        // Toasts get the room they need, the context and filter what is left
+        toast := truncate(mark+model.Toast.Text, width-lipgloss.Width(right))
+        right = style.Render(toast) + right
+    }
+
+    if room := width - lipgloss.Width(right) - 1; room > 0 {
+        left = truncate(left, room)
+    } else {
+        left = ""
+    }
+    gap := max(width-lipgloss.Width(left)-lipgloss.Width(right), 0)
+    return truncate(left+strings.Repeat(" ", gap)+right, width)
+}
@@ -0,0 +1,83 @@
+package ui
+
+import (
+    "os"
+
+    "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/themes"
+
+    "github.com/charmbracelet/lipgloss"
+)
+
+// Styles are built from a color scheme when the TUI starts, or when the
+// theme changes
+type Styles struct {
+    Theme    themes.ColorScheme
+    Primary  lipgloss.Style
+    Selected lipgloss.Style
+    Muted    lipgloss.Style
+    Error    lipgloss.Style
+    Text     lipgloss.Style
+    Code     lipgloss.Style
+    Window   lipgloss.Style
+    Popup    lipgloss.Style
+}
+
+func NewStyles(scheme themes.ColorScheme) Styles {
+    color := func(c string) lipgloss.TerminalColor {
+        if c == "" {
+            return lipgloss.NoColor{}
+        }
+        return lipgloss.Color(c)
+    }
+    border := lipgloss.NewStyle().
+        Foreground(color(scheme.DefaultFg)).
+        BorderForeground(color(scheme.BorderColor)).
+        Border(lipgloss.RoundedBorder())
+
+    return Styles{
+        Theme:    scheme,
+        Primary:  lipgloss.NewStyle().Foreground(color(scheme.PrimaryFg)),
+        Selected: lipgloss.NewStyle().Foreground(color(scheme.SelectedFg)).Bold(true),
+        Muted:    lipgloss.NewStyle().Foreground(color(scheme.MutedFg)),
+        Error:    lipgloss.NewStyle().Foreground(color(scheme.ErrorFg)),
+        Text:     lipgloss.NewStyle().Foreground(color(scheme.DefaultFg)),
+        Code:     lipgloss.NewStyle().Foreground(color(scheme.SelectedFg)),
+        Window:   border,
+        Popup:    border,
+    }
+}
+
+// LoadTheme picks the color scheme for cfg: none when NO_COLOR is set, the
+// configured theme, or otherwise a light or dark theme matching the
+// terminal's background. User themes that fail to load are returned as a
+// warning, and are only an error when one of them is the theme picked.
+func LoadTheme(cfg *config.Config) (scheme themes.ColorScheme, warning error, err error) {
+    if os.Getenv("NO_COLOR") != "" {
+        return themes.NoColor, nil, nil
+    }
+
+    registry := themes.NewRegistry()
+    if dir, err := themes.UserDir(); err == nil {
+        warning = registry.LoadDir(dir)
+    }
+
+    name := cfg.Theme
+    if name == "" || name == themes.Auto {
+        // Asks the terminal, so only done once the TUI is starting
+        if lipgloss.HasDarkBackground() {
+            if cfg.DarkTheme == "" {
+                return themes.DefaultDark, warning, nil
+            }
+            name = cfg.DarkTheme
+        } else {
+            if cfg.LightTheme == "" {
+                return themes.DefaultLight, warning, nil
+            }
+            name = cfg.LightTheme
+        }
+    }
+
+    scheme, err = registry.Lookup(name)
+    return scheme, warning, err
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+    "os"
+    "path/filepath"
+    "testing"
+
+    "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/themes"
+
+    "github.com/charmbracelet/lipgloss"
+)
+
+func TestLoadTheme(t *testing.T) {
+    home := t.TempDir()
+    t.Setenv("HOME", home)
+    dir := filepath.Join(home, ".jot", "themes")
+    if err := os.MkdirAll(dir, 0755); err != nil {
+        t.Fatal(err)
+    }
+    if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0644); err != nil {
+        t.Fatal(err)
+    }
+    defer lipgloss.SetHasDarkBackground(lipgloss.HasDarkBackground())
+
+    tests := []struct {
+        name    string
+        noColor bool
+        dark    bool
+        cfg     config.Config
+        want    string
+        wantErr bool
+    }{
+        {"no color", true, true, config.Config{Theme: "gruvbox-dark"}, "none", false},
+        {"configured", false, true, config.Config{Theme: "gruvbox-light"}, "gruvbox-light", false},
+        {"auto dark", false, true, config.Config{}, themes.DefaultDark.Name, false},
+        {"auto light", false, false, config.Config{Theme: themes.Auto}, themes.DefaultLight.Name, false},
+        {"dark theme", false, true, config.Config{DarkTheme: "catppuccin-mocha", LightTheme: "gruvbox-light"}, "catppuccin-mocha", false},
+        {"light theme", false, false, config.Config{DarkTheme: "catppuccin-mocha", LightTheme: "gruvbox-light"}, "gruvbox-light", false},
+        {"unknown", false, true, config.Config{Theme: "nope"}, "", true},
+        {"broken", false, true, config.Config{Theme: "broken"}, "", true},
+    }
+    for _, test := range tests {
+        noColor := ""
+        if test.noColor {
+            noColor = "1"
+        }
+        t.Setenv("NO_COLOR", noColor)
+        lipgloss.SetHasDarkBackground(test.dark)
+
+        scheme, warning, err := LoadTheme(&test.cfg)
+        if (err != nil) != test.wantErr {
+            t.Errorf("%s: error = %v, want error %v", test.name, err, test.wantErr)
+        }
+        if scheme.Name != test.want {
+            t.Errorf("%s: theme = %q, want %q", test.name, scheme.Name, test.want)
+        }
+        // The broken file only matters once themes are read
+        if !test.noColor && warning == nil {
+            t.Errorf("%s: no warning about the broken theme", test.name)
+        }
+    }
+}
@@ -0,0 +1,311 @@
+package ui
+
+import (
+    "fmt"
+    "sort"
+    "strings"
+
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/charmbracelet/bubbles/key"
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+// treeNode is a project, ticket, branch or note in the tree view. Group
+// nodes have a nil Note.
+type treeNode struct {
+    Key      string
+    Label    string
+    Scope    gitctx.Scope
+    Ticket   string
+    Note     *storage.Note
+    Count    int
+    Parent   *treeNode
+    Children []*treeNode
+}
+
+// treeRow is a node visible in the tree view at the given depth
+type treeRow struct {
+    Node  *treeNode
+    Depth int
+}
+
+// buildTree groups notes as project → ticket → branch → note. Notes without
+// a ticket sit directly under their project, and project-wide notes under
+// a "project-wide" branch node.
+func buildTree(notes []*storage.Note) []*treeNode {
+    var roots []*treeNode
+    index := make(map[string]*treeNode)
+
+    child := func(parent *treeNode, key, label string) *treeNode {
+        if node, ok := index[key]; ok {
+            return node
+        }
+        node := &treeNode{Key: key, Label: label, Parent: parent}
+        if parent != nil {
+            node.Scope = parent.Scope
+            node.Ticket = parent.Ticket
+            parent.Children = append(parent.Children, node)
+        } else {
+            roots = append(roots, node)
+        }
+        index[key] = node
+        return node
+    }
+
+    for _, note := range notes {
+        scope := note.Scope()
+        project := child(nil, scope.Label(), scope.Label())
+        project.Scope = gitctx.Scope{Project: scope.Project, SubProject: scope.SubProject, Branch: "*"}
+
+        parent := project
+        if note.Ticket != "" {
+            parent = child(project, project.Key+"\x00"+note.Ticket, note.Ticket)
+            parent.Ticket = note.Ticket
+        }
+
+        branchLabel := note.Branch
+        if note.Branch == "*" || note.Branch == "" {
+            branchLabel = "project-wide"
+        }
+        branch := child(parent, parent.Key+"\x00"+note.Branch, branchLabel)
+        branch.Scope.Branch = note.Branch
+
+        leaf := &treeNode{Key: note.ID, Label: note.Title, Scope: branch.Scope,
+            Ticket: note.Ticket, Note: note, Parent: branch}
+        branch.Children = append(branch.Children, leaf)
+        for node := branch; node != nil; node = node.Parent {
+            node.Count++
+        }
+    }
+
+    sortTree(roots)
+    return roots
+}
+
+// sortTree orders groups by label; notes keep the order they were listed in
+func sortTree(nodes []*treeNode) {
+    if len(nodes) == 0 || nodes[0].Note != nil {
+        return
+    }
+    sort.SliceStable(nodes, func(i, j int) bool {
+        return nodes[i].Label < nodes[j].Label
+    })
+    for _, node := range nodes {
+        sortTree(node.Children)
+    }
+}
+
+// flattenTree lists the nodes that are visible given the collapsed groups
+func flattenTree(nodes []*treeNode, collapsed map[string]bool, depth int, rows []treeRow) []treeRow {
+    for _, node := range nodes {
+        rows = append(rows, treeRow{Node: node, Depth: depth})
+        if node.Note == nil && !collapsed[node.Key] {
+            rows = flattenTree(node.Children, collapsed, depth+1, rows)
+        }
+    }
+    return rows
+}
+
+// rebuildTree refreshes the visible tree rows from the displayed notes,
+// keeping the cursor on the same node where possible
+func (model *Model) rebuildTree() {
+    if !model.TreeMode {
+        return
+    }
+    selectedKey := ""
+    if model.Cursor >= 0 && model.Cursor < len(model.TreeRows) {
+        selectedKey = model.TreeRows[model.Cursor].Node.Key
+    }
+
+    model.TreeRows = flattenTree(buildTree(model.DisplayedNotes), model.Collapsed, 0, nil)
+
+    for i, row := range model.TreeRows {
+        if row.Node.Key == selectedKey {
+            model.Cursor = i
+            return
+        }
+    }
+    if model.Cursor >= len(model.TreeRows) {
+        model.Cursor = max(len(model.TreeRows)-1, 0)
+    }
+}
+
+func (model *Model) toggleTree() {
+    // Read the selection in the view being left
+    selected := model.selectedNote()
+    model.TreeMode = !model.TreeMode
+    model.TreeRows = nil
+    model.Cursor = 0
+    model.rebuildTree()
+
+    // Keep the selected note under the cursor when switching views
+    if selected == nil {
+        return
+    }
+    if model.TreeMode {
+        for node := model.findTreeNote(selected.ID); node != nil; node = node.Parent {
+            delete(model.Collapsed, node.Key)
+        }
+        model.rebuildTree()
+        for i, row := range model.TreeRows {
+            if row.Node.Note == selected {
+                model.Cursor = i
+            }
+        }
+    } else {
+        for i, note := range model.DisplayedNotes {
+            if note == selected {
+                model.Cursor = i
+            }
+        }
+    }
+}
+
+func (model Model) findTreeNote(id string) *treeNode {
+    var find func(nodes []*treeNode) *treeNode
+    find = func(nodes []*treeNode) *treeNode {
+        for _, node := range nodes {
+            if node.Note != nil && node.Note.ID == id {
+                return node
+            }
+            if found := find(node.Children); found != nil {
+                return found
+            }
+        }
+        return nil
+    }
+    return find(buildTree(model.DisplayedNotes))
+}
+
+// selectedTreeNode returns the node under the cursor in tree mode
+func (model Model) selectedTreeNode() *treeNode {
+    if !model.TreeMode || model.Cursor < 0 || model.Cursor >= len(model.TreeRows) {
+        return nil
+    }
+    return model.TreeRows[model.Cursor].Node
+}
+
+// updateTreeMode handles the keys specific to the tree view. handled is
+// false for keys shared with the flat list.
+func (model Model) updateTreeMode(msg tea.KeyMsg) (tea.Model, tea.Cmd, bool) {
+    node := model.selectedTreeNode()
+    if node == nil {
+        return model, nil, false
+    }
+
+    keys := model.Keys
+    switch {
+    case key.Matches(msg, keys.Expand):
+        if node.Note == nil {
+            delete(model.Collapsed, node.Key)
+            model.rebuildTree()
+        }
+    case key.Matches(msg, keys.Collapse):
+        if node.Note == nil && !model.Collapsed[node.Key] {
+            model.Collapsed[node.Key] = true
+            model.rebuildTree()
+        } else {
+            model.jumpToParent(node)
+        }
+    case key.Matches(msg, keys.Parent):
+        model.jumpToParent(node)
+    case key.Matches(msg, keys.Open):
+        if node.Note != nil {
+            return model, nil, false
+        }
+        model.Collapsed[node.Key] = !model.Collapsed[node.Key]
+        model.rebuildTree()
+    case key.Matches(msg, keys.OpenScope):
+        // Open the scope's own note, creating it like `jot branch` would
+        updated, cmd := model.openScopeNote(node)
+        return updated, cmd, true
+    case key.Matches(msg, keys.New):
+        updated, cmd := model.startNewNote(node.Scope, node.Ticket)
+        return updated, cmd, true
+    default:
+        return model, nil, false
+    }
+    return model, nil, true
+}
+
+func (model *Model) jumpToParent(node *treeNode) {
+    if node.Parent == nil {
+        return
+    }
+    for i, row := range model.TreeRows {
+        if row.Node == node.Parent {
+            model.Cursor = i
+            return
+        }
+    }
+}
+
+// openScopeNote opens the note named after the node's branch (or project for
+// project-wide nodes), creating it if the scope has no such note
+func (model Model) openScopeNote(node *treeNode) (Model, tea.Cmd) {
+    if node.Note != nil {
+        return model, model.openNote(node.Note.ID)
+    }
+
+    title := node.Scope.Branch
+    if title == "*" {
+        title = node.Label
+        if node.Ticket != "" {
+            title = node.Ticket
+        }
+    }
+    for _, note := range model.Notes {
+        if note.Scope() == node.Scope && note.Title == title {
+            return model, model.openNote(note.ID)
+        }
+    }
+
+    newNote := storage.Note{
+        Title:      title,
+        Project:    node.Scope.Project,
+        SubProject: node.Scope.SubProject,
+        Branch:     node.Scope.Branch,
+        Ticket:     node.Ticket,
+    }
+    createdNote, err := model.Store.Create(newNote)
+    if err != nil {
+        model.fail(fmt.Errorf("error creating note: %w", err))
+        return model, nil
+    }
+    model.Notes = append(model.Notes, createdNote)
+    model.ApplyFilter(model.CurrentFilter)
+    return model, model.openCreatedNote(createdNote.ID)
+}
+
+// treeRowView renders tree row i, truncated to width
+func (model Model) treeRowView(i int, width int) string {
+    row := model.TreeRows[i]
+    node := row.Node
+    indent := strings.Repeat("  ", row.Depth)
+    var line string
+    if node.Note != nil {
+        label := node.Label
+        if model.hasSelection() {
+            label = selectionMark(model.isSelected(i, node.Note)) + label
+        }
+        line = indent + "  " + label
+    } else {
+        marker := "▼ "
+        if model.Collapsed[node.Key] {
+            marker = "▶ "
+        }
+        line = indent + marker + node.Label + fmt.Sprintf(" (%d)", node.Count)
+    }
+    line = truncate(line, width)
+
+    switch {
+    case i == model.Cursor:
+        return model.Styles.Selected.Render(line)
+    case node.Note == nil, model.isSelected(i, node.Note):
+        return model.Styles.Primary.Render(line)
+    default:
+        return model.Styles.Muted.Render(line)
+    }
+}
@@ -0,0 +1,25 @@
+package ui
+
+import "testing"
+
+func TestToggleTreeKeepsSelection(t *testing.T) {
+    notes := testNotes(6)
+    model := Model{Notes: notes, DisplayedNotes: notes, Collapsed: make(map[string]bool)}
+    model.Cursor = 4
+
+    model.toggleTree()
+    if note := model.selectedNote(); note == nil || note.ID != "N4" {
+        t.Fatalf("tree selection = %v, want N4", note)
+    }
+
+    model.Cursor = 0
+    for i, row := range model.TreeRows {
+        if row.Node.Note != nil && row.Node.Note.ID == "N5" {
+            model.Cursor = i
+        }
+    }
+    model.toggleTree()
+    if note := model.selectedNote(); note == nil || note.ID != "N5" {
+        t.Errorf("list selection = %v, want N5", note)
+    }
+}
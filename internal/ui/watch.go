@@ -0,0 +1,122 @@
+package ui
+
+import (
+    "os"
+    "time"
+
+    "github.com/JonLD/jot/internal/storage"
+
+    tea "github.com/charmbracelet/bubbletea"
+)
+
+// How often the TUI checks for notes changed by other processes
+const pollInterval = time.Second
+
+type notesLoadedMsg struct {
+    notes []*storage.Note
+}
+
+// pollMsg reports the state of the store and note files, so changes made
+// by jot.nvim, other jot processes or editors can be picked up
+type pollMsg struct {
+    version int64
+    files   time.Time
+    err     error
+}
+
+// watchState is what the last poll saw
+type watchState struct {
+    polled  bool
+    version int64
+    files   time.Time
+}
+
+// loadNotes reads every note, first catching up modified times with files
+// edited outside jot
+func (model Model) loadNotes() tea.Cmd {
+    store := model.Store
+    return func() tea.Msg {
+        store.SyncModifiedTimes()
+        notes, _ := store.GetAll()
+        return notesLoadedMsg{notes}
+    }
+}
+
+// poll checks the store's data version and the note files' latest
+// modification after pollInterval, off the update loop. Only the files of
+// known notes are checked: a note made elsewhere adds a row, which changes
+// the data version, and files without a row aren't notes.
+func (model Model) poll() tea.Cmd {
+    store := model.Store
+    paths := make([]string, 0, len(model.Notes))
+    for _, note := range model.Notes {
+        paths = append(paths, note.Path)
+    }
+    return tea.Tick(pollInterval, func(time.Time) tea.Msg {
+        version, err := store.DataVersion()
+        return pollMsg{version: version, files: latestModification(paths), err: err}
+    })
+}
+
+// latestModification is the newest modification time of the files that
+// exist among paths
+func latestModification(paths []string) time.Time {
+    var latest time.Time
+    for _, path := range paths {
+        if info, err := os.Stat(path); err == nil && info.ModTime().After(latest) {
+            latest = info.ModTime()
+        }
+    }
+    return latest
+}
+
+func (model Model) updatePoll(msg pollMsg) (tea.Model, tea.Cmd) {
+    if msg.err != nil {
+        // Keep polling, the store may be busy
+        return model, model.poll()
+    }
+    previous := model.Watch
+    model.Watch = watchState{polled: true, version: msg.version, files: msg.files}
+    if !previous.polled || (previous.version == msg.version && previous.files.Equal(msg.files)) {
+        return model, model.poll()
+    }
+    return model, tea.Batch(model.loadNotes(), model.poll())
+}
+
+// reloadNotes replaces the notes, keeping the filter, search and the
+// cursor on the same note. It returns the content search to rerun, if any.
+func (model *Model) reloadNotes(notes []*storage.Note) tea.Cmd {
+    var selectedID string
+    if note := model.selectedNote(); note != nil {
+        selectedID = note.ID
+    }
+    cursor := model.Cursor
+    displayed, anchor := model.DisplayedNotes, model.VisualAnchor
+
+    model.Notes = notes
+    storage.PruneTodoCache(notes)
+    model.ApplyFilter(model.CurrentFilter)
+    searchCmd := model.applySearch()
+    // Filtering shows every note in scope before the search narrows them
+    // again, so compare the end result to keep the visual range
+    if sameNotes(displayed, model.DisplayedNotes) {
+        model.VisualAnchor = anchor
+    }
+
+    // The tree keeps its own selection when it is rebuilt
+    model.Cursor = cursor
+    if !model.TreeMode {
+        for i, note := range model.DisplayedNotes {
+            if note.ID == selectedID {
+                model.Cursor = i
+            }
+        }
+    }
+
+    if model.ShowPreview && model.PreviewNoteID == selectedID {
+        offset := model.Preview.YOffset
+        model.refreshPreview()
+        model.Preview.SetYOffset(offset)
+    }
+    return searchCmd
+}
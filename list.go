@@ -0,0 +1,97 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "text/tabwriter"
+
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/spf13/cobra"
+)
+
+type ListFlags struct {
+    Sort     string
+    Branch   bool
+    Project  bool
+    Archived bool
+    Query    string
+}
+
+var listFlags = &ListFlags{}
+
+var listCmd = &cobra.Command{
+    Use:     "list",
+    Aliases: []string{"ls"},
+    Short:   "List notes",
+    Args:    cobra.NoArgs,
+    RunE: func(cmd *cobra.Command, args []string) error {
+        store, err := initializeApp()
+        if err != nil {
+            return err
+        }
+        defer store.Close()
+
+        sortName := listFlags.Sort
+        if sortName == "" {
+            sortName = cfg.Sort
+        }
+        sortMode, err := storage.ParseSortMode(sortName)
+        if err != nil {
+            return err
+        }
+
+        query, err := storage.ParseQuery(listFlags.Query)
+        if err != nil {
+            return err
+        }
+
+        notes, err := store.GetAll()
+        if err != nil {
+            return fmt.Errorf("error fetching notes: %v", err)
+        }
+
+        scope, _ := resolver.Context().Scope(gitctx.DetachedGlobal)
+        var listed []*storage.Note
+        for _, note := range notes {
+            if note.Archived && !listFlags.Archived {
+                continue
+            }
+            if listFlags.Project && (note.Project != scope.Project || note.SubProject != scope.SubProject) {
+                continue
+            }
+            if listFlags.Branch && note.Scope() != scope {
+                continue
+            }
+            listed = append(listed, note)
+        }
+
+        storage.SortNotes(listed, sortMode)
+        // Fuzzy matched titles come out best match first
+        printNotes(query.Filter(listed))
+        return nil
+    },
+}
+
+func init() {
+    listCmd.Flags().StringVarP(&listFlags.Sort, "sort", "s", "",
+        "Sort by frecency, modified, created, title or scope (default from config, else frecency)")
+    listCmd.Flags().BoolVarP(&listFlags.Branch, "branch", "b", false, "Only notes of the current branch")
+    listCmd.Flags().BoolVarP(&listFlags.Project, "project", "p", false, "Only notes of the current project")
+    listCmd.Flags().BoolVar(&listFlags.Archived, "archived", false, "Include archived notes")
+    listCmd.Flags().StringVarP(&listFlags.Query, "query", "q", "",
+        "Only notes matching a search, e.g. \"api project:jot -tag:wip modified:<7d\"")
+
+    rootCmd.AddCommand(listCmd)
+}
+
+// printNotes writes one aligned line per note: modified time, scope, title and ID
+func printNotes(notes []*storage.Note) {
+    w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+    for _, note := range notes {
+        fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", note.ModifiedAt.Format("2006-01-02 15:04"),
+            noteScope(note), note.Title, note.ID)
+    }
+    w.Flush()
+}
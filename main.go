@@ -1,15 +1,17 @@
 package main
 
 import (
+    "bufio"
     "fmt"
     "log"
     "os"
-    "os/exec"
-    "path/filepath"
+    "slices"
     "strings"
+    "github.com/JonLD/jot/internal/gitctx"
     "github.com/JonLD/jot/internal/storage"
     "github.com/JonLD/jot/internal/ui"
     "github.com/JonLD/jot/internal/config"
+    "github.com/JonLD/jot/themes"
 
     "github.com/spf13/cobra"
     tea "github.com/charmbracelet/bubbletea"
@@ -19,12 +21,19 @@ type CLIFlags struct {
     OpenNote     string
     BranchNote   bool
     FromNvim     bool
+    ListThemes   bool
+    Query        string
 }
 
 type ConfigFlags struct {
     Editor           string
     EditorBackground string
     DefaultMode      string
+    DetachedHead     string
+    GitNotes         string
+    Compact          string
+    KeyPreset        string
+    Theme            string
 }
 
 var (
@@ -32,6 +41,8 @@ var (
     cliFlags    = &CLIFlags{}
     configFlags = &ConfigFlags{}
 	cfg *config.Config
+	// resolver is shared by every command so git runs once per invocation
+	resolver gitctx.Resolver = gitctx.NewResolver("")
 )
 
 var rootCmd = &cobra.Command{
@@ -43,7 +54,8 @@ var rootCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
-        return runJot(store, ui.FilterDisplayAll)
+		defer store.Close()
+        return runJot(store, resolver, ui.FilterDisplayAll)
     },
 }
 
@@ -56,9 +68,12 @@ var openCmd = &cobra.Command{
         if err != nil {
             return err
         }
-		project := getCurrentProject()
-		branch := getCurrentBranch()
-        return handleOpenNote(store, args[0], project, branch, fromNvim)
+        defer store.Close()
+		scope, err := resolveScope(resolver.Context())
+		if err != nil {
+			return err
+		}
+        return handleOpenNote(store, resolver, args[0], scope, fromNvim)
     },
 }
 
@@ -70,15 +85,16 @@ var branchCmd = &cobra.Command{
         if err != nil {
             return err
         }
+        defer store.Close()
 
+		scope, err := resolveScope(resolver.Context())
+		if err != nil {
+			return err
+		}
 		if len(args) == 1 {
-            branch := getCurrentBranch()
-            project := getCurrentProject()
-			return handleOpenNote(store, args[0], project, branch, fromNvim)
+			return handleOpenNote(store, resolver, args[0], scope, fromNvim)
 		}
-		project := getCurrentProject()
-		branch := getCurrentBranch()
-        return handleContextNote(store, project, branch, fromNvim)
+        return handleContextNote(store, resolver, scope, fromNvim)
     },
 }
 
@@ -91,14 +107,16 @@ var projectCmd = &cobra.Command{
         if err != nil {
             return err
         }
+        defer store.Close()
 
-        project := getCurrentProject()
+        scope, _ := resolver.Context().Scope(gitctx.DetachedGlobal)
+        scope.Branch = "*"
 
         if len(args) == 1 {
-            return handleOpenNote(store, args[0], project, "*", fromNvim)
+            return handleOpenNote(store, resolver, args[0], scope, fromNvim)
         }
 
-        return handleContextNote(store, project, "*", fromNvim)
+        return handleContextNote(store, resolver, scope, fromNvim)
     },
 }
 
@@ -130,6 +148,20 @@ func init() {
     rootCmd.Flags().StringVarP(&configFlags.EditorBackground,
         "editor-background", "", "", "Set editor background mode")
     rootCmd.Flags().StringVarP(&configFlags.DefaultMode, "default-mode", "m", "", "Set default mode")
+    rootCmd.Flags().StringVarP(&configFlags.DetachedHead,
+        "detached-head", "", "", "Where notes go on a detached HEAD (global, prompt, revision)")
+    rootCmd.Flags().StringVarP(&configFlags.GitNotes,
+        "git-notes", "", "", "Export linked notes to refs/notes/jot (true, false)")
+    rootCmd.Flags().StringVarP(&configFlags.Compact,
+        "compact", "", "", "Hide the TUI banner (true, false)")
+    rootCmd.Flags().StringVarP(&configFlags.KeyPreset,
+        "key-preset", "", "", "Set the TUI keymap ("+strings.Join(ui.KeyPresets(), ", ")+")")
+    rootCmd.Flags().StringVarP(&configFlags.Theme,
+        "theme", "", "", "Set the TUI theme (auto, or a name from --list-themes)")
+    rootCmd.Flags().BoolVar(&cliFlags.ListThemes,
+        "list-themes", false, "List built-in and user themes")
+    rootCmd.Flags().StringVarP(&cliFlags.Query,
+        "query", "q", "", "Start the TUI searching for a query (same syntax as the search box)")
 
 	rootCmd.PersistentFlags().BoolVar(&fromNvim, "fromnvim", false, "Called from Neovim (internal)")
 
@@ -138,7 +170,16 @@ func init() {
 	rootCmd.AddCommand(projectCmd)
 }
 
-func runJot(store storage.NoteStore, filter ui.FilterFunc) error {
+func runJot(store storage.NoteStore, repo gitctx.Resolver, filter ui.FilterFunc) error {
+    if cliFlags.ListThemes {
+        registry, err := loadThemes()
+        if err != nil {
+            return err
+        }
+        fmt.Println(strings.Join(registry.Names(), "\n"))
+        return nil
+    }
+
     // Handle config updates first
     if hasConfigFlags(configFlags) {
         if err := updateConfigFromFlags(configFlags); err != nil {
@@ -147,8 +188,21 @@ func runJot(store storage.NoteStore, filter ui.FilterFunc) error {
         fmt.Println("Configuration updated successfully")
         return nil
     }
-    startTUI(store, filter)
-    return nil
+    return startTUI(store, repo, filter)
+}
+
+// loadThemes returns the built-in themes plus those in ~/.jot/themes,
+// warning about theme files that fail to load
+func loadThemes() (*themes.Registry, error) {
+    registry := themes.NewRegistry()
+    dir, err := themes.UserDir()
+    if err != nil {
+        return nil, err
+    }
+    if err := registry.LoadDir(dir); err != nil {
+        fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+    }
+    return registry, nil
 }
 
 func main() {
@@ -159,7 +213,9 @@ func main() {
 }
 
 func hasConfigFlags(flags *ConfigFlags) bool {
-    return flags.Editor != "" || flags.EditorBackground != "" || flags.DefaultMode != ""
+    return flags.Editor != "" || flags.EditorBackground != "" || flags.DefaultMode != "" ||
+        flags.DetachedHead != "" || flags.GitNotes != "" || flags.Compact != "" ||
+        flags.KeyPreset != "" || flags.Theme != ""
 }
 
 func updateConfigFromFlags(flags *ConfigFlags) error {
@@ -187,23 +243,83 @@ func updateConfigFromFlags(flags *ConfigFlags) error {
             return fmt.Errorf("invalid default mode: %s", flags.DefaultMode)
         }
     }
+
+    if flags.DetachedHead != "" {
+        switch flags.DetachedHead {
+        case gitctx.DetachedGlobal, gitctx.DetachedPrompt, gitctx.DetachedRevision:
+            cfg.DetachedHead = flags.DetachedHead
+            modified = true
+        default:
+            return fmt.Errorf("invalid value for detached-head: %s", flags.DetachedHead)
+        }
+    }
+
+    if flags.GitNotes != "" {
+        if flags.GitNotes == "true" || flags.GitNotes == "false" {
+            cfg.GitNotes = flags.GitNotes == "true"
+            modified = true
+        } else {
+            return fmt.Errorf("invalid value for git-notes: %s", flags.GitNotes)
+        }
+    }
+
+    if flags.Compact != "" {
+        if flags.Compact == "true" || flags.Compact == "false" {
+            cfg.Compact = flags.Compact == "true"
+            modified = true
+        } else {
+            return fmt.Errorf("invalid value for compact: %s", flags.Compact)
+        }
+    }
+
+    if flags.KeyPreset != "" {
+        if !slices.Contains(ui.KeyPresets(), flags.KeyPreset) {
+            return fmt.Errorf("invalid key preset: %s", flags.KeyPreset)
+        }
+        cfg.KeyPreset = flags.KeyPreset
+        modified = true
+    }
+
+    if flags.Theme != "" {
+        if flags.Theme != themes.Auto {
+            registry, err := loadThemes()
+            if err != nil {
+                return err
+            }
+            if _, err := registry.Lookup(flags.Theme); err != nil {
+                return fmt.Errorf("invalid theme: %w", err)
+            }
+        }
+        cfg.Theme = flags.Theme
+        modified = true
+    }
     if modified {
         return cfg.Save()
     }
     return nil
 }
 
-func startTUI(store storage.NoteStore, filter ui.FilterFunc) {
-    model := ui.NewModel(store, filter)
-    p := tea.NewProgram(model)
+func startTUI(store storage.NoteStore, repo gitctx.Resolver, filter ui.FilterFunc) error {
+    model, err := ui.NewModel(store, cfg, repo, filter)
+    if err != nil {
+        return fmt.Errorf("invalid TUI settings in config: %w", err)
+    }
+    if cliFlags.Query != "" {
+        if _, err := storage.ParseQuery(cliFlags.Query); err != nil {
+            return err
+        }
+        model.SearchInputText.SetValue(cliFlags.Query)
+    }
+    p := tea.NewProgram(model, tea.WithMouseCellMotion())
     p.Run()
+    return nil
 }
 
 func handleOpenNote(
 	store storage.NoteStore,
+	repo gitctx.Resolver,
 	query string,
-	project string,
-	branch string,
+	scope gitctx.Scope,
 	fromNvim bool,
 ) error {
     // Try to find note by title first, then by ID
@@ -214,7 +330,7 @@ func handleOpenNote(
 
     var foundNote *storage.Note
     for _, note := range notes {
-		matchingDetails := note.Title == query && note.Project == project && note.Branch == branch
+		matchingDetails := note.Title == query && note.Scope() == scope
         if (matchingDetails) || note.ID == query {
             foundNote = note
             break
@@ -224,9 +340,11 @@ func handleOpenNote(
     // If note doesn't exist, create it
     if foundNote == nil {
         note := storage.Note{
-            Title:   query,
-            Project: project,
-            Branch:  branch,
+            Title:      query,
+            Project:    scope.Project,
+            SubProject: scope.SubProject,
+            Branch:     scope.Branch,
+            Ticket:     repo.Context().TicketFor(scope),
         }
 
         createdNote, err := store.Create(note)
@@ -238,7 +356,10 @@ func handleOpenNote(
 
     // Check if called from Neovim
     if fromNvim {
-        // Inside Neovim - just output the path
+        // Inside Neovim - just output the path, which jot.nvim opens
+        if err := store.RecordOpen(foundNote.ID); err != nil {
+            return fmt.Errorf("error recording open: %v", err)
+        }
         fmt.Print(foundNote.Path)
     } else {
         // Outside Neovim - open with default editor
@@ -250,7 +371,12 @@ func handleOpenNote(
 	return nil
 }
 
-func handleContextNote(store storage.NoteStore, project string, branch string, fromNvim bool) error {
+func handleContextNote(
+	store storage.NoteStore,
+	repo gitctx.Resolver,
+	scope gitctx.Scope,
+	fromNvim bool,
+) error {
     // Try to find existing note for this project/branch combination
     notes, err := store.GetAll()
     if err != nil {
@@ -259,22 +385,27 @@ func handleContextNote(store storage.NoteStore, project string, branch string, f
 
     var foundNotes []*storage.Note
     for _, note := range notes {
-        if note.Project == project && note.Branch == branch {
+        if note.Scope() == scope {
             foundNotes = append(foundNotes, note)
         }
     }
 
     // If note doesn't exist, create it
     if len(foundNotes) == 0 {
-        defaultTitle := branch
-        if branch == "*" {
-            defaultTitle = project
+        defaultTitle := scope.Branch
+        if scope.Branch == "*" {
+            defaultTitle = scope.Project
+            if scope.SubProject != "" {
+                defaultTitle = scope.SubProject
+            }
         }
 
         note := storage.Note{
-            Title:   defaultTitle,
-            Project: project,
-            Branch:  branch,
+            Title:      defaultTitle,
+            Project:    scope.Project,
+            SubProject: scope.SubProject,
+            Branch:     scope.Branch,
+            Ticket:     repo.Context().TicketFor(scope),
         }
 
         createdNote, err := store.Create(note)
@@ -287,16 +418,19 @@ func handleContextNote(store storage.NoteStore, project string, branch string, f
 	// If multiple notes then open TUI with appropriate filter
 	if len(foundNotes) > 1 {
         var filter ui.FilterFunc
-        if branch == "*" {
+        if scope.Branch == "*" {
             filter = ui.FilterByProject
         } else {
             filter = ui.FilterByBranch
         }
-		return runJot(store, filter)
+		return runJot(store, repo, filter)
 	} else { // Only one note so open it immediately
 		// Check if called from Neovim
 		if fromNvim {
 			// Just output path for jot.nvim to open
+			if err := store.RecordOpen(foundNotes[0].ID); err != nil {
+				return fmt.Errorf("error recording open: %v", err)
+			}
 			fmt.Print(foundNotes[0].Path)
 		} else {
 			// Outside Neovim - open with default editor
@@ -309,39 +443,24 @@ func handleContextNote(store storage.NoteStore, project string, branch string, f
     return nil
 }
 
-// Helper function to get current Git branch
-func getCurrentBranch() string {
-    cmd := exec.Command("git", "branch", "--show-current")
-    output, err := cmd.Output()
-    if err != nil {
-        return "main" // fallback
+// resolveScope returns where notes created from the current directory belong.
+// When HEAD is detached and the detached_head policy is "prompt", the user is
+// asked for a branch, defaulting to a project-wide note.
+func resolveScope(repo gitctx.Context) (gitctx.Scope, error) {
+    scope, ok := repo.Scope(cfg.DetachedHead)
+    if ok || fromNvim {
+        // jot.nvim cannot answer a prompt, so it gets the default
+        return scope, nil
     }
-    return strings.TrimSpace(string(output))
-}
 
-// Helper function to get current Git project name
-func getCurrentProject() string {
-    // Try to get project name from git remote
-    cmd := exec.Command("git", "remote", "get-url", "origin")
-    output, err := cmd.Output()
-    if err == nil {
-        url := strings.TrimSpace(string(output))
-        // Extract project name from URL (handle both SSH and HTTPS)
-        if strings.Contains(url, "/") {
-            parts := strings.Split(url, "/")
-            projectName := parts[len(parts)-1]
-            // Remove .git suffix if present
-            projectName = strings.TrimSuffix(projectName, ".git")
-            if projectName != "" {
-                return projectName
-            }
-        }
+    fmt.Fprintf(os.Stderr, "HEAD is detached at %s. Branch for this note [%s]: ",
+        repo.Revision(), scope.Branch)
+    answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+    if err != nil && answer == "" {
+        return scope, fmt.Errorf("error reading branch: %w", err)
     }
-
-    // Fallback to directory name
-    cwd, err := os.Getwd()
-    if err != nil {
-        return "unknown"
+    if answer = strings.TrimSpace(answer); answer != "" {
+        scope.Branch = answer
     }
-    return filepath.Base(cwd)
+    return scope, nil
 }
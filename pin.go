@@ -0,0 +1,72 @@
+package main
+
+import (
+    "fmt"
+
+    "github.com/JonLD/jot/internal/gitctx"
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/spf13/cobra"
+)
+
+var pinGlobal bool
+
+var pinCmd = &cobra.Command{
+    Use:   "pin [note]",
+    Short: "Pin a note to the top of the TUI list",
+    Long: `Pin a note to the top of the TUI list, above a separator, whatever the
+filter or sort. The note is pinned in its own project's lists, or in every
+list with --global.
+
+The note is matched by ID, then by title in the current project and branch.`,
+    Args: cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        pin := storage.PinProject
+        if pinGlobal {
+            pin = storage.PinGlobal
+        }
+        return setPin(args[0], pin)
+    },
+}
+
+var unpinCmd = &cobra.Command{
+    Use:   "unpin [note]",
+    Short: "Return a pinned note to its sorted place",
+    Args:  cobra.ExactArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        return setPin(args[0], storage.PinNone)
+    },
+}
+
+func init() {
+    pinCmd.Flags().BoolVarP(&pinGlobal, "global", "g", false, "Pin in every project's lists")
+
+    rootCmd.AddCommand(pinCmd)
+    rootCmd.AddCommand(unpinCmd)
+}
+
+func setPin(query string, pin storage.Pin) error {
+    store, err := initializeApp()
+    if err != nil {
+        return err
+    }
+    defer store.Close()
+    scope, _ := resolver.Context().Scope(gitctx.DetachedGlobal)
+    note, err := findNote(store, query, scope)
+    if err != nil {
+        return err
+    }
+    if err := store.SetPinned(note.ID, pin); err != nil {
+        return fmt.Errorf("error updating note: %v", err)
+    }
+
+    switch pin {
+    case storage.PinGlobal:
+        fmt.Printf("Pinned '%s' in every project\n", note.Title)
+    case storage.PinProject:
+        fmt.Printf("Pinned '%s' in %s\n", note.Title, note.Scope().Label())
+    default:
+        fmt.Printf("Unpinned '%s'\n", note.Title)
+    }
+    return nil
+}
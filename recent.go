@@ -0,0 +1,92 @@
+package main
+
+import (
+    "fmt"
+    "os"
+    "strconv"
+    "text/tabwriter"
+
+    "github.com/JonLD/jot/internal/storage"
+
+    "github.com/spf13/cobra"
+)
+
+var recentOpen int
+
+var recentCmd = &cobra.Command{
+    Use:   "recent [count]",
+    Short: "List the most used notes across all projects",
+    Long: "List the notes opened most often and most recently, from the CLI, the TUI or jot.nvim.\n" +
+        "--open opens the note at a rank instead.",
+    Args: cobra.MaximumNArgs(1),
+    RunE: func(cmd *cobra.Command, args []string) error {
+        count := 10
+        if len(args) == 1 {
+            var err error
+            count, err = strconv.Atoi(args[0])
+            if err != nil || count < 1 {
+                return fmt.Errorf("invalid count: %s", args[0])
+            }
+        }
+
+        store, err := initializeApp()
+        if err != nil {
+            return err
+        }
+        defer store.Close()
+        notes, err := recentNotes(store)
+        if err != nil {
+            return err
+        }
+
+        if recentOpen > 0 {
+            if recentOpen > len(notes) {
+                return fmt.Errorf("only %d notes have been opened", len(notes))
+            }
+            note := notes[recentOpen-1]
+            if fromNvim {
+                if err := store.RecordOpen(note.ID); err != nil {
+                    return fmt.Errorf("error recording open: %v", err)
+                }
+                fmt.Print(note.Path)
+                return nil
+            }
+            if err := store.Open(note.ID); err != nil {
+                return fmt.Errorf("error opening note: %v", err)
+            }
+            return nil
+        }
+
+        w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+        for i, note := range notes[:min(count, len(notes))] {
+            fmt.Fprintf(w, "%d\t%s\t%d×\t%s\t%s\t%s\n", i+1,
+                note.LastOpenedAt.Format("2006-01-02 15:04"), note.OpenCount,
+                noteScope(note), note.Title, note.ID)
+        }
+        w.Flush()
+        return nil
+    },
+}
+
+func init() {
+    recentCmd.Flags().IntVarP(&recentOpen, "open", "o", 0, "Open the note at this rank")
+
+    rootCmd.AddCommand(recentCmd)
+}
+
+// recentNotes returns the unarchived notes that have been opened, by
+// frecency
+func recentNotes(store storage.NoteStore) ([]*storage.Note, error) {
+    notes, err := store.GetAll()
+    if err != nil {
+        return nil, fmt.Errorf("error fetching notes: %v", err)
+    }
+    var opened []*storage.Note
+    for _, note := range notes {
+        if note.OpenCount > 0 && !note.Archived {
+            opened = append(opened, note)
+        }
+    }
+    storage.SortNotes(opened, storage.SortFrecency)
+    return opened, nil
+}
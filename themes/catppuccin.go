@@ -0,0 +1,27 @@
+package themes
+
+var CatppuccinMochaScheme = ColorScheme{
+    Name:        "catppuccin-mocha",
+    Dark:        true,
+    DefaultFg:   "#cdd6f4",
+    MutedFg:     "#6c7086",
+    SelectedFg:  "#94e2d5",
+    PrimaryFg:   "#cba6f7",
+    ErrorFg:     "#f38ba8",
+    DefaultBg:   "#1e1e2e",
+    PopupBg:     "#313244",
+    BorderColor: "#585b70",
+}
+
+var CatppuccinLatteScheme = ColorScheme{
+    Name:        "catppuccin-latte",
+    Dark:        false,
+    DefaultFg:   "#4c4f69",
+    MutedFg:     "#8c8fa1",
+    SelectedFg:  "#179299",
+    PrimaryFg:   "#8839ef",
+    ErrorFg:     "#d20f39",
+    DefaultBg:   "#eff1f5",
+    PopupBg:     "#ccd0da",
+    BorderColor: "#acb0be",
+}
@@ -0,0 +1,27 @@
+package themes
+
+var GruvboxDarkScheme = ColorScheme{
+    Name:        "gruvbox-dark",
+    Dark:        true,
+    DefaultFg:   "#ebdbb2",
+    MutedFg:     "#928374",
+    SelectedFg:  "#8ec07c",
+    PrimaryFg:   "#fabd2f",
+    ErrorFg:     "#fb4934",
+    DefaultBg:   "#282828",
+    PopupBg:     "#3c3836",
+    BorderColor: "#665c54",
+}
+
+var GruvboxLightScheme = ColorScheme{
+    Name:        "gruvbox-light",
+    Dark:        false,
+    DefaultFg:   "#3c3836",
+    MutedFg:     "#928374",
+    SelectedFg:  "#427b58",
+    PrimaryFg:   "#b57614",
+    ErrorFg:     "#9d0006",
+    DefaultBg:   "#fbf1c7",
+    PopupBg:     "#ebdbb2",
+    BorderColor: "#bdae93",
+}
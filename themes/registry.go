@@ -0,0 +1,138 @@
+package themes
+
+import (
+    "encoding/json"
+    "errors"
+    "fmt"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+// Auto picks DefaultDark or DefaultLight to match the terminal background
+const Auto = "auto"
+
+var (
+    DefaultDark  = TokyoNightScheme
+    DefaultLight = TokyoNightDayScheme
+
+    // NoColor leaves every color unset, for NO_COLOR
+    NoColor = ColorScheme{Name: "none"}
+)
+
+// Builtin lists the schemes shipped with jot
+var Builtin = []ColorScheme{
+    TokyoNightScheme,
+    TokyoNightDayScheme,
+    GruvboxDarkScheme,
+    GruvboxLightScheme,
+    CatppuccinMochaScheme,
+    CatppuccinLatteScheme,
+    NoColor,
+}
+
+// Registry holds the built-in schemes and any user themes, by name
+type Registry struct {
+    schemes map[string]ColorScheme
+    // broken holds why theme files failed to load, by file name less .json
+    broken map[string]error
+}
+
+func NewRegistry() *Registry {
+    registry := &Registry{schemes: make(map[string]ColorScheme), broken: make(map[string]error)}
+    for _, scheme := range Builtin {
+        registry.schemes[scheme.Name] = scheme
+    }
+    return registry
+}
+
+// UserDir is where user themes live: ~/.jot/themes
+func UserDir() (string, error) {
+    homeDir, err := os.UserHomeDir()
+    if err != nil {
+        return "", err
+    }
+    return filepath.Join(homeDir, ".jot", "themes"), nil
+}
+
+// LoadDir adds every *.json theme in dir, replacing built-ins of the same
+// name. A theme without a name is named after its file. A missing directory
+// is not an error. Unreadable or invalid files are skipped and returned as
+// an error once the rest are loaded, which callers can treat as a warning:
+// Lookup only fails for the themes that could not be loaded.
+func (registry *Registry) LoadDir(dir string) error {
+    paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+    if err != nil {
+        return err
+    }
+
+    var errs []error
+    for _, path := range paths {
+        scheme, err := loadFile(path)
+        if err != nil {
+            err = fmt.Errorf("theme %s: %w", filepath.Base(path), err)
+            registry.broken[strings.TrimSuffix(filepath.Base(path), ".json")] = err
+            errs = append(errs, err)
+            continue
+        }
+        registry.schemes[scheme.Name] = scheme
+    }
+    return errors.Join(errs...)
+}
+
+func loadFile(path string) (ColorScheme, error) {
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return ColorScheme{}, err
+    }
+    var scheme ColorScheme
+    if err := json.Unmarshal(data, &scheme); err != nil {
+        return ColorScheme{}, err
+    }
+    if scheme.Name == "" {
+        scheme.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+    }
+
+    fallback := DefaultLight
+    if scheme.Dark {
+        fallback = DefaultDark
+    }
+    fill := func(color *string, fallback string) {
+        if *color == "" {
+            *color = fallback
+        }
+    }
+    fill(&scheme.DefaultFg, fallback.DefaultFg)
+    fill(&scheme.MutedFg, fallback.MutedFg)
+    fill(&scheme.SelectedFg, fallback.SelectedFg)
+    fill(&scheme.PrimaryFg, fallback.PrimaryFg)
+    fill(&scheme.ErrorFg, fallback.ErrorFg)
+    fill(&scheme.DefaultBg, fallback.DefaultBg)
+    fill(&scheme.PopupBg, fallback.PopupBg)
+    fill(&scheme.BorderColor, fallback.BorderColor)
+    return scheme, nil
+}
+
+// Lookup returns the scheme called name, explaining why when it is unknown
+// or its file failed to load
+func (registry *Registry) Lookup(name string) (ColorScheme, error) {
+    if scheme, ok := registry.schemes[name]; ok {
+        return scheme, nil
+    }
+    if err, ok := registry.broken[name]; ok {
+        return ColorScheme{}, err
+    }
+    return ColorScheme{}, fmt.Errorf("unknown theme %q (available: %s)",
+        name, strings.Join(registry.Names(), ", "))
+}
+
+// Names lists the known themes alphabetically
+func (registry *Registry) Names() []string {
+    var names []string
+    for name := range registry.schemes {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,56 @@
+package themes
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+)
+
+func TestRegistryLookup(t *testing.T) {
+    dir := t.TempDir()
+    files := map[string]string{
+        "mine.json":    `{"dark": true, "primary_fg": "#ff0000"}`,
+        "gruvbox.json": `{"name": "gruvbox-dark", "default_fg": "#000000"}`,
+        "broken.json":  `{"name": `,
+    }
+    for name, content := range files {
+        if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+            t.Fatal(err)
+        }
+    }
+    registry := NewRegistry()
+    if err := registry.LoadDir(dir); err == nil || !strings.Contains(err.Error(), "broken.json") {
+        t.Errorf("LoadDir error = %v, want one naming broken.json", err)
+    }
+
+    tests := []struct {
+        name    string
+        wantErr string
+        check   func(ColorScheme) bool
+    }{
+        {"tokyonight", "", func(s ColorScheme) bool { return s == TokyoNightScheme }},
+        // A file without a name is named after it, and unset colors come
+        // from the default scheme of its background
+        {"mine", "", func(s ColorScheme) bool {
+            return s.PrimaryFg == "#ff0000" && s.DefaultFg == DefaultDark.DefaultFg
+        }},
+        {"gruvbox-dark", "", func(s ColorScheme) bool { return s.DefaultFg == "#000000" }},
+        {"broken", "broken.json", nil},
+        {"missing", `unknown theme "missing"`, nil},
+    }
+    for _, test := range tests {
+        scheme, err := registry.Lookup(test.name)
+        if test.wantErr != "" {
+            if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+                t.Errorf("Lookup(%q) error = %v, want one containing %s", test.name, err, test.wantErr)
+            }
+            continue
+        }
+        if err != nil {
+            t.Errorf("Lookup(%q): %v", test.name, err)
+        } else if !test.check(scheme) {
+            t.Errorf("Lookup(%q) = %+v", test.name, scheme)
+        }
+    }
+}
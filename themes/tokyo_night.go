@@ -62,6 +62,8 @@ var TokyoNightPalette = struct {
 }
 
 var TokyoNightScheme = ColorScheme{
+    Name:        "tokyonight",
+    Dark:        true,
     DefaultFg:   TokyoNightPalette.Foreground,
     MutedFg:     TokyoNightPalette.Comment,
     SelectedFg:  TokyoNightPalette.Cyan,
@@ -70,4 +72,18 @@ var TokyoNightScheme = ColorScheme{
     DefaultBg:   TokyoNightPalette.Background,
     PopupBg:     TokyoNightPalette.BackgroundFloat,
     BorderColor: TokyoNightPalette.Blue7,
-}
\ No newline at end of file
+}
+
+// TokyoNightDayScheme is the light variant of Tokyo Night
+var TokyoNightDayScheme = ColorScheme{
+    Name:        "tokyonight-day",
+    Dark:        false,
+    DefaultFg:   "#3760bf",
+    MutedFg:     "#848cb5",
+    SelectedFg:  "#007197",
+    PrimaryFg:   "#2e7de9",
+    ErrorFg:     "#f52a65",
+    DefaultBg:   "#e1e2e7",
+    PopupBg:     "#d0d5e3",
+    BorderColor: "#92a6d5",
+}
@@ -1,12 +1,17 @@
 package themes
 
+// ColorScheme is the set of colors the TUI draws with. User themes are JSON
+// files using the field tags below; colors they leave out come from the
+// default scheme of the same background.
 type ColorScheme struct {
-    DefaultFg   string
-    MutedFg     string
-    SelectedFg  string
-    PrimaryFg   string
-    ErrorFg     string
-    DefaultBg   string
-    PopupBg     string
-    BorderColor string
-}
\ No newline at end of file
+    Name        string `json:"name"`
+    Dark        bool   `json:"dark"`
+    DefaultFg   string `json:"default_fg"`
+    MutedFg     string `json:"muted_fg"`
+    SelectedFg  string `json:"selected_fg"`
+    PrimaryFg   string `json:"primary_fg"`
+    ErrorFg     string `json:"error_fg"`
+    DefaultBg   string `json:"default_bg"`
+    PopupBg     string `json:"popup_bg"`
+    BorderColor string `json:"border_color"`
+}